@@ -0,0 +1,108 @@
+package evaluator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/SealNTibbers/GotalkInterpreter/parser"
+	"github.com/SealNTibbers/GotalkInterpreter/treeNodes"
+)
+
+// methodsForPattern matches chunks like Gauge methodsFor: 'accessing' or Gauge class methodsFor: 'instance creation'
+var methodsForPattern = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)(\s+class)?\s+methodsFor:\s*'(?:[^']|'')*'(?:\s+stamp:\s*'(?:[^']|'')*')?\s*$`)
+
+// FileIn evaluates source in the chunk format of Pharo file outs. Every chunk ends with ! and !! is a ! inside of a chunk.
+// Class definitions and other expressions are evaluated, and a chunk like !Gauge methodsFor: 'accessing'! or
+// !Gauge class methodsFor: 'instance creation'! starts methods of the class, which end with an empty chunk:
+//
+//	Object subclass: #Gauge
+//		instanceVariableNames: 'min max'
+//		classVariableNames: ''
+//		package: 'Widgets'!
+//
+//	!Gauge methodsFor: 'accessing'!
+//	span
+//		^max - min
+//	! !
+//
+// Classes are defined in the global scope of the evaluator, so all its programs see them.
+func (e *Evaluator) FileIn(source string) error {
+	var class *treeNodes.SmalltalkClass
+	classSide := false
+	for i, chunk := range chunksOf(source) {
+		if class != nil {
+			if strings.TrimSpace(chunk) == "" {
+				class = nil
+				continue
+			}
+			method, err := parser.ParseMethod(chunk)
+			if err != nil {
+				return fmt.Errorf("chunk %d: %w", i+1, err)
+			}
+			if classSide {
+				err = class.AddClassMethod(method)
+			} else {
+				err = class.AddMethod(method)
+			}
+			if err != nil {
+				return fmt.Errorf("chunk %d: %w", i+1, err)
+			}
+			e.clearCache()
+			continue
+		}
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+		if match := methodsForPattern.FindStringSubmatch(chunk); match != nil {
+			object, err := e.evaluateChunk(match[1])
+			if err != nil {
+				return fmt.Errorf("chunk %d: %w", i+1, err)
+			}
+			typedClass, ok := object.(*treeNodes.SmalltalkClass)
+			if !ok {
+				return fmt.Errorf("chunk %d: %s is not a class", i+1, match[1])
+			}
+			class = typedClass
+			classSide = match[2] != ""
+			continue
+		}
+		_, err := e.evaluateChunk(chunk)
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// evaluateChunk evaluates an expression of a file in. It is not cached, so the expression is evaluated every time.
+func (e *Evaluator) evaluateChunk(chunk string) (treeNodes.SmalltalkObjectInterface, error) {
+	program, err := parser.InitializeParserFor(chunk)
+	if err != nil {
+		return nil, err
+	}
+	return e.EvaluateProgramE(program)
+}
+
+// chunksOf splits source at every ! which is not doubled
+func chunksOf(source string) []string {
+	var chunks []string
+	var chunk strings.Builder
+	for i := 0; i < len(source); i++ {
+		if source[i] != '!' {
+			chunk.WriteByte(source[i])
+			continue
+		}
+		if i+1 < len(source) && source[i+1] == '!' {
+			chunk.WriteByte('!')
+			i++
+			continue
+		}
+		chunks = append(chunks, chunk.String())
+		chunk.Reset()
+	}
+	if strings.TrimSpace(chunk.String()) != "" {
+		chunks = append(chunks, chunk.String())
+	}
+	return chunks
+}
@@ -1,6 +1,10 @@
 package evaluator
 
 import (
+	"fmt"
+	"math/big"
+	"runtime/debug"
+
 	"github.com/SealNTibbers/GotalkInterpreter/parser"
 	"github.com/SealNTibbers/GotalkInterpreter/treeNodes"
 )
@@ -48,6 +52,7 @@ type Evaluator struct {
 	globalScope    *treeNodes.Scope
 	programCache   map[string]treeNodes.ProgramNodeInterface
 	workspaceScope *treeNodes.Scope
+	numericPolicy  treeNodes.NumericPolicy
 }
 
 func (e *Evaluator) SetGlobalScope(scope *treeNodes.Scope) *Evaluator {
@@ -55,25 +60,53 @@ func (e *Evaluator) SetGlobalScope(scope *treeNodes.Scope) *Evaluator {
 	return e
 }
 
+// SetNumericPolicy tells what divisions by zero and functions like sqrt outside of their domain answer:
+// IEEE infinities and NaN (the default), ZeroDivide and DomainError errors or the default value of the policy
+func (e *Evaluator) SetNumericPolicy(policy treeNodes.NumericPolicy) *Evaluator {
+	e.numericPolicy = policy
+	e.clearCache()
+	return e
+}
+
+func (e *Evaluator) GetNumericPolicy() treeNodes.NumericPolicy {
+	return e.numericPolicy
+}
+
 func (e *Evaluator) GetGlobalScope() *treeNodes.Scope {
 	return e.globalScope
 }
 
+// RunProgram answers the result of programString evaluation or nil if it fails. Use Evaluate to get the error.
 func (e *Evaluator) RunProgram(programString string) treeNodes.SmalltalkObjectInterface {
+	result, _ := e.Evaluate(programString)
+	return result
+}
+
+// Evaluate answers the result of programString evaluation or one of ParseError, UndefinedVariableError,
+// DoesNotUnderstandError, TypeMismatchError etc. if programString can't be parsed or evaluated.
+func (e *Evaluator) Evaluate(programString string) (treeNodes.SmalltalkObjectInterface, error) {
 	_, ok := e.programCache[programString]
 	if !ok {
 		initializedParser, err := parser.InitializeParserFor(programString)
 		if err != nil {
-			return treeNodes.NewSmalltalkString(err.Error())
+			return nil, err
 		}
 		e.programCache[programString] = initializedParser
 	}
 	evaluatorProgram := e.programCache[programString]
-	return e.EvaluateProgram(evaluatorProgram)
+	return e.EvaluateProgramE(evaluatorProgram)
 }
 
+// EvaluateProgram answers the result of program evaluation or nil if it fails. Use EvaluateProgramE to get the error.
 func (e *Evaluator) EvaluateProgram(program treeNodes.ProgramNodeInterface) treeNodes.SmalltalkObjectInterface {
-	var result treeNodes.SmalltalkObjectInterface
+	result, _ := e.EvaluateProgramE(program)
+	return result
+}
+
+func (e *Evaluator) EvaluateProgramE(program treeNodes.ProgramNodeInterface) (result treeNodes.SmalltalkObjectInterface, err error) {
+	if program.GetLastValue() != nil {
+		return program.GetLastValue(), nil
+	}
 	var localScope *treeNodes.Scope
 	if e.workspaceScope != nil {
 		localScope = e.workspaceScope
@@ -81,58 +114,240 @@ func (e *Evaluator) EvaluateProgram(program treeNodes.ProgramNodeInterface) tree
 		localScope = new(treeNodes.Scope).Initialize()
 	}
 	localScope.OuterScope = e.globalScope
+	localScope.SetNumericPolicy(e.numericPolicy)
+	localScope.TrackSideEffects()
 
-	if program.GetLastValue() == nil {
-		result = program.Eval(localScope)
+	// embedded applications must survive even a broken primitive
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = nil
+			err = &treeNodes.InternalError{Recovered: recovered, Stack: debug.Stack()}
+		}
+	}()
+	result, err = treeNodes.Activate(program, localScope)
+	if localScope.DefinedClass() {
+		e.clearCache()
+	}
+	if err != nil {
+		return nil, err
+	}
+	// only results of programs without messages to classes, instances or Go objects are cached, so the
+	// cached result is the result of evaluating the program again
+	if !localScope.HasSideEffects() {
 		program.SetLastValue(result)
-	} else {
-		result = program.GetLastValue()
 	}
+	return result, nil
+}
 
+func (e *Evaluator) EvaluateToString(programString string) string {
+	result, _ := e.EvaluateToStringE(programString)
 	return result
 }
 
-func (e *Evaluator) EvaluateToString(programString string) string {
-	resultObject := e.RunProgram(programString)
-	return resultObject.(*treeNodes.SmalltalkString).GetValue()
+func (e *Evaluator) EvaluateToStringE(programString string) (string, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return "", err
+	}
+	stringObject, ok := resultObject.(*treeNodes.SmalltalkString)
+	if !ok {
+		return "", &treeNodes.TypeMismatchError{Expected: treeNodes.STRING_OBJ, Actual: typeOf(resultObject)}
+	}
+	return stringObject.GetValue(), nil
 }
 
 func (e *Evaluator) EvaluateToFloat64(programString string) float64 {
-	resultObject := e.RunProgram(programString)
-	return resultObject.(*treeNodes.SmalltalkNumber).GetValue()
+	result, _ := e.EvaluateToFloat64E(programString)
+	return result
+}
+
+func (e *Evaluator) EvaluateToFloat64E(programString string) (float64, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return 0, err
+	}
+	numberObject, ok := resultObject.(*treeNodes.SmalltalkNumber)
+	if !ok {
+		return 0, &treeNodes.TypeMismatchError{Expected: treeNodes.NUMBER_OBJ, Actual: typeOf(resultObject)}
+	}
+	return numberObject.GetValue(), nil
 }
 
 func (e *Evaluator) EvaluateToInt64(programString string) int64 {
-	return int64(e.EvaluateToFloat64(programString))
+	result, _ := e.EvaluateToInt64E(programString)
+	return result
+}
+
+// EvaluateToInt64E answers NotAnIntegerError if the result has a fraction part or does not fit int64
+func (e *Evaluator) EvaluateToInt64E(programString string) (int64, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return 0, err
+	}
+	numberObject, ok := resultObject.(*treeNodes.SmalltalkNumber)
+	if !ok {
+		return 0, &treeNodes.TypeMismatchError{Expected: treeNodes.NUMBER_OBJ, Actual: typeOf(resultObject)}
+	}
+	result, ok := numberObject.GetInt64()
+	if !ok {
+		return 0, &treeNodes.NotAnIntegerError{Number: numberObject.PrintString()}
+	}
+	return result, nil
+}
+
+func (e *Evaluator) EvaluateToRat(programString string) *big.Rat {
+	result, _ := e.EvaluateToRatE(programString)
+	return result
+}
+
+// EvaluateToRatE answers the exact value of a number. Fractions and scaled decimals keep all digits, floats are
+// converted from their decimal text, so 0.1 is 1/10.
+func (e *Evaluator) EvaluateToRatE(programString string) (*big.Rat, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return nil, err
+	}
+	numberObject, ok := resultObject.(*treeNodes.SmalltalkNumber)
+	if !ok {
+		return nil, &treeNodes.TypeMismatchError{Expected: treeNodes.NUMBER_OBJ, Actual: typeOf(resultObject)}
+	}
+	result, ok := numberObject.GetRat()
+	if !ok {
+		return nil, fmt.Errorf("%s has no exact value", numberObject.PrintString())
+	}
+	return result, nil
 }
 
 func (e *Evaluator) EvaluateToBool(programString string) bool {
-	resultObject := e.RunProgram(programString)
-	return resultObject.(*treeNodes.SmalltalkBoolean).GetValue()
+	result, _ := e.EvaluateToBoolE(programString)
+	return result
+}
+
+func (e *Evaluator) EvaluateToBoolE(programString string) (bool, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return false, err
+	}
+	booleanObject, ok := resultObject.(*treeNodes.SmalltalkBoolean)
+	if !ok {
+		return false, &treeNodes.TypeMismatchError{Expected: treeNodes.BOOLEAN_OBJ, Actual: typeOf(resultObject)}
+	}
+	return booleanObject.GetValue(), nil
 }
 
 func (e *Evaluator) EvaluateToInterface(programString string) interface{} {
-	resultObject := e.RunProgram(programString)
+	result, _ := e.EvaluateToInterfaceE(programString)
+	return result
+}
+
+func (e *Evaluator) EvaluateToInterfaceE(programString string) (interface{}, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return nil, err
+	}
+	return interfaceValueOf(resultObject)
+}
+
+func (e *Evaluator) EvaluateToExactInterface(programString string) interface{} {
+	result, _ := e.EvaluateToExactInterfaceE(programString)
+	return result
+}
+
+// EvaluateToExactInterfaceE answers the same values as EvaluateToInterfaceE, except that a SmallInteger result is int64
+// instead of float64
+func (e *Evaluator) EvaluateToExactInterfaceE(programString string) (interface{}, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return nil, err
+	}
+	if numberObject, ok := resultObject.(*treeNodes.SmalltalkNumber); ok {
+		return numberObject.GetExactInterfaceValue(), nil
+	}
+	return interfaceValueOf(resultObject)
+}
+
+// interfaceValueOf answers the Go value of a result like EvaluateToInterfaceE does
+func interfaceValueOf(resultObject treeNodes.SmalltalkObjectInterface) (interface{}, error) {
 	if resultObject == nil {
-		return nil
+		return nil, nil
 	}
 	switch resultObject.TypeOf() {
 	case treeNodes.UNDEFINED_OBJ:
-		return nil
+		return nil, nil
 	case treeNodes.NUMBER_OBJ:
-		return resultObject.(*treeNodes.SmalltalkNumber).GetValue()
+		return resultObject.(*treeNodes.SmalltalkNumber).GetInterfaceValue(), nil
 	case treeNodes.STRING_OBJ:
-		return resultObject.(*treeNodes.SmalltalkString).GetValue()
+		return resultObject.(*treeNodes.SmalltalkString).GetValue(), nil
+	case treeNodes.SYMBOL_OBJ:
+		return resultObject.(*treeNodes.SmalltalkSymbol).GetValue(), nil
+	case treeNodes.CHARACTER_OBJ:
+		return treeNodes.Character(resultObject.(*treeNodes.SmalltalkCharacter).GetValue()), nil
 	case treeNodes.BOOLEAN_OBJ:
-		return resultObject.(*treeNodes.SmalltalkBoolean).GetValue()
+		return resultObject.(*treeNodes.SmalltalkBoolean).GetValue(), nil
 	case treeNodes.ARRAY_OBJ:
-		array, err := resultObject.(*treeNodes.SmalltalkArray).GetValue()
+		return resultObject.(*treeNodes.SmalltalkArray).GetValue()
+	case treeNodes.INTERVAL_OBJ:
+		return resultObject.(*treeNodes.SmalltalkInterval).GetValue(), nil
+	case treeNodes.BYTE_ARRAY_OBJ:
+		return resultObject.(*treeNodes.SmalltalkByteArray).GetValue(), nil
+	case treeNodes.ORDERED_COLLECTION_OBJ, treeNodes.SET_OBJ, treeNodes.BAG_OBJ, treeNodes.DICTIONARY_OBJ, treeNodes.GO_OBJ:
+		return treeNodes.InterfaceValue(resultObject)
+	default:
+		return nil, nil
+	}
+}
+
+func (e *Evaluator) EvaluateToSlice(programString string) []interface{} {
+	result, _ := e.EvaluateToSliceE(programString)
+	return result
+}
+
+// EvaluateToSliceE answers elements of an array, an interval, an ordered collection, a set or a bag converted like EvaluateToInterface does
+func (e *Evaluator) EvaluateToSliceE(programString string) ([]interface{}, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return nil, err
+	}
+	switch resultObject.(type) {
+	case *treeNodes.SmalltalkArray, *treeNodes.SmalltalkInterval, *treeNodes.SmalltalkOrderedCollection, *treeNodes.SmalltalkSet, *treeNodes.SmalltalkBag:
+		value, err := treeNodes.InterfaceValue(resultObject)
 		if err != nil {
-			return nil
+			return nil, err
 		}
-		return array
+		return value.([]interface{}), nil
 	default:
-		return nil
+		return nil, &treeNodes.TypeMismatchError{Expected: treeNodes.ARRAY_OBJ, Actual: typeOf(resultObject)}
+	}
+}
+
+func (e *Evaluator) EvaluateToMap(programString string) map[string]interface{} {
+	result, _ := e.EvaluateToMapE(programString)
+	return result
+}
+
+func (e *Evaluator) EvaluateToMapE(programString string) (map[string]interface{}, error) {
+	resultObject, err := e.Evaluate(programString)
+	if err != nil {
+		return nil, err
+	}
+	dictionary, ok := resultObject.(*treeNodes.SmalltalkDictionary)
+	if !ok {
+		return nil, &treeNodes.TypeMismatchError{Expected: treeNodes.DICTIONARY_OBJ, Actual: typeOf(resultObject)}
+	}
+	return dictionary.GetValue()
+}
+
+func typeOf(object treeNodes.SmalltalkObjectInterface) string {
+	if object == nil {
+		return treeNodes.UNDEFINED_OBJ
+	}
+	return object.TypeOf()
+}
+
+// clearCache makes all programs evaluate again
+func (e *Evaluator) clearCache() {
+	for _, evaluatorProgram := range e.programCache {
+		evaluatorProgram.SetLastValue(nil)
 	}
 }
 
@@ -166,11 +381,44 @@ func (e *Evaluator) SetNumberVar(name string, value float64) treeNodes.Smalltalk
 	return e.globalScope.SetNumberVar(name, value)
 }
 
+func (e *Evaluator) SetIntegerVar(name string, value int64) treeNodes.SmalltalkObjectInterface {
+	e.updateCache(name)
+	return e.globalScope.SetIntegerVar(name, value)
+}
+
 func (e *Evaluator) SetBoolVar(name string, value bool) treeNodes.SmalltalkObjectInterface {
 	e.updateCache(name)
 	return e.globalScope.SetBoolVar(name, value)
 }
 
+// SetSliceVar sets the variable to an Array of value elements converted with treeNodes.NewSmalltalkObjectFrom
+func (e *Evaluator) SetSliceVar(name string, value []interface{}) (treeNodes.SmalltalkObjectInterface, error) {
+	return e.setGoVar(name, value)
+}
+
+// SetMapVar sets the variable to a Dictionary with String keys and values converted with treeNodes.NewSmalltalkObjectFrom
+func (e *Evaluator) SetMapVar(name string, value map[string]interface{}) (treeNodes.SmalltalkObjectInterface, error) {
+	return e.setGoVar(name, value)
+}
+
+// SetGoObject sets the variable to a proxy of value, so scripts read and set its exported fields and send its exported
+// methods (see treeNodes.NewSmalltalkGoObject). Give a pointer to a struct to let scripts set its fields.
+func (e *Evaluator) SetGoObject(name string, value interface{}) (treeNodes.SmalltalkObjectInterface, error) {
+	object, err := treeNodes.NewSmalltalkGoObject(value)
+	if err != nil {
+		return nil, err
+	}
+	return e.SetVar(name, object), nil
+}
+
+func (e *Evaluator) setGoVar(name string, value interface{}) (treeNodes.SmalltalkObjectInterface, error) {
+	object, err := treeNodes.NewSmalltalkObjectFrom(value)
+	if err != nil {
+		return nil, err
+	}
+	return e.SetVar(name, object), nil
+}
+
 func (e *Evaluator) FindValueByName(name string) (treeNodes.SmalltalkObjectInterface, bool) {
 	e.updateCache(name)
 	return e.globalScope.FindValueByName(name)
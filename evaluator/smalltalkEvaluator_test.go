@@ -1,8 +1,14 @@
 package evaluator
 
 import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"sync"
 	"testing"
 
+	"github.com/SealNTibbers/GotalkInterpreter/parser"
 	"github.com/SealNTibbers/GotalkInterpreter/testutils"
 	"github.com/SealNTibbers/GotalkInterpreter/treeNodes"
 )
@@ -525,3 +531,1538 @@ func TestAPI(t *testing.T) {
 	testutils.ASSERT_FALSE(t, vm.EvaluateToInterface(smalltalkProgram4).(bool))
 
 }
+
+func TestCascadeEvaluation(t *testing.T) {
+	var inputString string
+	var resultObject treeNodes.SmalltalkObjectInterface
+
+	inputString = `3 + 4; * 10`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 30)
+
+	inputString = `#(1 2 3) at: 1; at: 3`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 3)
+
+	inputString = `(2 + 3) negated; abs; max: 7`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 7)
+}
+
+func TestCascadeWithChangingScopeEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetNumberVar("x", 2)
+	vm.SetNumberVar("y", 10)
+
+	inputString := `x + 1; + y`
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(inputString)), 12)
+
+	vm.SetNumberVar("y", 20)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(inputString)), 22)
+
+	vm.SetNumberVar("x", 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(inputString)), 25)
+}
+
+func TestReturnEvaluation(t *testing.T) {
+	var inputString string
+	var resultObject treeNodes.SmalltalkObjectInterface
+
+	inputString = `^ 3 + 4`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 7)
+
+	inputString = `|x| x := -4. x < 0 ifTrue: [^0]. x sqrt`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 0)
+
+	inputString = `|x| x := 16. x < 0 ifTrue: [^0]. x sqrt`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 4)
+
+	inputString = `([:v | v > 2 ifTrue: [^v * 10]. v] value: 5) + 1`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 50)
+
+	inputString = `([:v | v > 2 ifTrue: [^v * 10]. v] value: 1) + 1`
+	resultObject = TestEval(inputString)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_FLOAT64_EQ(t, resultObject.(*treeNodes.SmalltalkNumber).GetValue(), 2)
+}
+
+func TestReturnFromDeadContextEvaluation(t *testing.T) {
+	programNode, err := parser.InitializeParserFor(`[:x | ^x]`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	block, err := treeNodes.Activate(programNode, new(treeNodes.Scope).Initialize())
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_TRUE(t, block.TypeOf() == treeNodes.BLOCK_OBJ)
+
+	_, err = block.(*treeNodes.SmalltalkBlock).ValueWithArguments([]treeNodes.SmalltalkObjectInterface{treeNodes.NewSmalltalkNumber(3)})
+	_, ok := err.(*treeNodes.BlockCannotReturnError)
+	testutils.ASSERT_TRUE(t, ok)
+
+	programNode, err = parser.InitializeParserFor(`[nil foo]`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	block, err = treeNodes.Activate(programNode, new(treeNodes.Scope).Initialize())
+	testutils.ASSERT_TRUE(t, err == nil)
+	_, err = block.(*treeNodes.SmalltalkBlock).ValueE()
+	_, ok = err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_TRUE(t, block.(*treeNodes.SmalltalkBlock).Value() == nil)
+
+	vm := NewSmalltalkWorkspace()
+	vm.RunProgram(`b := [:x | ^x]`)
+	testutils.ASSERT_TRUE(t, vm.RunProgram(`(b value: 3) + 1`) == nil)
+}
+
+func TestEvaluationErrors(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetNumberVar("x", 3)
+
+	_, err := vm.Evaluate(`x + )`)
+	parseError, ok := err.(*parser.ParseError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_EQ(t, int(parseError.Position), 5)
+
+	_, err = vm.Evaluate(`x ) 5`)
+	_, ok = err.(*parser.ParseError)
+	testutils.ASSERT_TRUE(t, ok)
+
+	_, err = vm.Evaluate(`'unterminated`)
+	parseError, ok = err.(*parser.ParseError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_EQ(t, int(parseError.Position), 1)
+
+	_, err = vm.Evaluate(`x + y`)
+	undefinedVariableError, ok := err.(*treeNodes.UndefinedVariableError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, undefinedVariableError.Name, "y")
+	testutils.ASSERT_EQ(t, int(undefinedVariableError.Position), 5)
+
+	_, err = vm.Evaluate(`x + 1 foo: 2`)
+	doesNotUnderstandError, ok := err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, doesNotUnderstandError.Selector, "foo:")
+	testutils.ASSERT_STREQ(t, doesNotUnderstandError.ReceiverType, treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_EQ(t, int(doesNotUnderstandError.Position), 7)
+
+	_, err = vm.Evaluate(`true ifTrue: [x bar]`)
+	doesNotUnderstandError, ok = err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, doesNotUnderstandError.Selector, "bar")
+	testutils.ASSERT_EQ(t, int(doesNotUnderstandError.Position), 17)
+
+	_, err = vm.Evaluate(`x + true`)
+	typeMismatchError, ok := err.(*treeNodes.TypeMismatchError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, typeMismatchError.Selector, "+")
+	testutils.ASSERT_STREQ(t, typeMismatchError.ReceiverType, treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_STREQ(t, typeMismatchError.Expected, treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_STREQ(t, typeMismatchError.Actual, treeNodes.BOOLEAN_OBJ)
+	testutils.ASSERT_EQ(t, int(typeMismatchError.Position), 3)
+	testutils.ASSERT_TRUE(t, vm.RunProgram(`x + true`) == nil)
+}
+
+func TestEvaluateToTypeErrors(t *testing.T) {
+	vm := NewSmalltalkVM()
+
+	_, err := vm.EvaluateToFloat64E(`'label'`)
+	typeMismatchError, ok := err.(*treeNodes.TypeMismatchError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, typeMismatchError.Expected, treeNodes.NUMBER_OBJ)
+	testutils.ASSERT_STREQ(t, typeMismatchError.Actual, treeNodes.STRING_OBJ)
+
+	_, err = vm.EvaluateToStringE(`42`)
+	_, ok = err.(*treeNodes.TypeMismatchError)
+	testutils.ASSERT_TRUE(t, ok)
+
+	_, err = vm.EvaluateToBoolE(`42`)
+	_, ok = err.(*treeNodes.TypeMismatchError)
+	testutils.ASSERT_TRUE(t, ok)
+
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`42`), "")
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`x +`), 0)
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`42`))
+
+	result, err := vm.EvaluateToFloat64E(`40 + 2`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_FLOAT64_EQ(t, result, 42)
+}
+
+func clampToAnd(receiver *treeNodes.SmalltalkNumber, min *treeNodes.SmalltalkNumber, max *treeNodes.SmalltalkNumber) *treeNodes.SmalltalkNumber {
+	return treeNodes.NewSmalltalkNumber(math.Max(min.GetValue(), math.Min(max.GetValue(), receiver.GetValue())))
+}
+
+func TestRegisterMethod(t *testing.T) {
+	vm := NewSmalltalkVM()
+
+	err := treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "clampTo:and:", clampToAnd)
+	testutils.ASSERT_TRUE(t, err == nil)
+	defer treeNodes.RemoveMethod(treeNodes.NUMBER_OBJ, "clampTo:and:")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`15 clampTo: 0 and: 10`)), 10)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`-5 clampTo: 0 and: 10`)), 0)
+
+	err = treeNodes.RegisterMethod(treeNodes.STRING_OBJ, "byteSize", func(receiver *treeNodes.SmalltalkString) treeNodes.SmalltalkObjectInterface {
+		return treeNodes.NewSmalltalkNumber(float64(len(receiver.GetValue())))
+	})
+	testutils.ASSERT_TRUE(t, err == nil)
+	defer treeNodes.RemoveMethod(treeNodes.STRING_OBJ, "byteSize")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`'gauge' byteSize`)), 5)
+
+	err = treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "clampTo:and:", func(receiver *treeNodes.SmalltalkNumber) *treeNodes.SmalltalkNumber {
+		return receiver
+	})
+	testutils.ASSERT_TRUE(t, err != nil)
+	err = treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "double", func(receiver *treeNodes.SmalltalkNumber) float64 {
+		return receiver.GetValue() * 2
+	})
+	testutils.ASSERT_TRUE(t, err != nil)
+	err = treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "+", func(receiver *treeNodes.SmalltalkNumber, arg float64) *treeNodes.SmalltalkNumber {
+		return receiver
+	})
+	testutils.ASSERT_TRUE(t, err != nil)
+	err = treeNodes.RegisterMethod("WIDGET", "double", clampToAnd)
+	testutils.ASSERT_TRUE(t, err != nil)
+
+	// a panicking primitive does not crash the application
+	err = treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "explode", func(receiver *treeNodes.SmalltalkNumber) *treeNodes.SmalltalkNumber {
+		panic("broken primitive")
+	})
+	testutils.ASSERT_TRUE(t, err == nil)
+	defer treeNodes.RemoveMethod(treeNodes.NUMBER_OBJ, "explode")
+	_, err = NewSmalltalkVM().Evaluate(`1 explode`)
+	internalError, ok := err.(*treeNodes.InternalError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, internalError.Error(), "internal error: broken primitive")
+	testutils.ASSERT_TRUE(t, len(internalError.Stack) > 0)
+}
+
+func TestRegisterMethodWhileEvaluating(t *testing.T) {
+	var wait sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			vm := NewSmalltalkVM()
+			vm.SetNumberVar("x", 2)
+			for j := 0; j < 200; j++ {
+				vm.SetNumberVar("x", float64(j))
+				vm.Evaluate(`(x + 1) triple`)
+			}
+		}()
+	}
+	for j := 0; j < 200; j++ {
+		treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "triple", func(receiver *treeNodes.SmalltalkNumber) *treeNodes.SmalltalkNumber {
+			return treeNodes.NewSmalltalkNumber(receiver.GetValue() * 3)
+		})
+		treeNodes.RemoveMethod(treeNodes.NUMBER_OBJ, "triple")
+	}
+	wait.Wait()
+}
+
+func TestOverrideAndRemoveBuiltInMethod(t *testing.T) {
+	vm := NewSmalltalkVM()
+	originalSqrt, ok := treeNodes.LookupMethod(treeNodes.NUMBER_OBJ, "sqrt")
+	testutils.ASSERT_TRUE(t, ok)
+	defer treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "sqrt", originalSqrt)
+
+	err := treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "sqrt", func(receiver *treeNodes.SmalltalkNumber) (*treeNodes.SmalltalkNumber, error) {
+		if receiver.GetValue() < 0 {
+			return nil, errors.New("negative sqrt")
+		}
+		return treeNodes.NewSmalltalkNumber(math.Sqrt(receiver.GetValue())), nil
+	})
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`16 sqrt`)), 4)
+	_, err = vm.Evaluate(`-16 sqrt`)
+	testutils.ASSERT_STREQ(t, err.Error(), "negative sqrt")
+
+	treeNodes.RemoveMethod(treeNodes.NUMBER_OBJ, "sqrt")
+	_, err = NewSmalltalkVM().Evaluate(`16 sqrt`)
+	_, ok = err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestPolymorphicSendEvaluation(t *testing.T) {
+	// the same message node sends #+ to numbers and to arrays
+	inputString := `|b| b := [:a | a + 1]. (b value: 1) + ((b value: #(1 2)) at: 2)`
+	evaluator := NewSmalltalkVM()
+	testutils.ASSERT_FLOAT64_EQ(t, evaluator.EvaluateToFloat64(inputString), 5)
+
+	treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "twice", func(receiver *treeNodes.SmalltalkNumber) *treeNodes.SmalltalkNumber {
+		return treeNodes.NewSmalltalkNumber(receiver.GetValue() * 2)
+	})
+	defer treeNodes.RemoveMethod(treeNodes.NUMBER_OBJ, "twice")
+	evaluator.SetNumberVar("x", 1)
+	testutils.ASSERT_FLOAT64_EQ(t, evaluator.EvaluateToFloat64(`x twice`), 2)
+	treeNodes.RegisterMethod(treeNodes.NUMBER_OBJ, "twice", func(receiver *treeNodes.SmalltalkNumber) *treeNodes.SmalltalkNumber {
+		return treeNodes.NewSmalltalkNumber(receiver.GetValue() + receiver.GetValue())
+	})
+	evaluator.SetNumberVar("x", 3)
+	testutils.ASSERT_FLOAT64_EQ(t, evaluator.EvaluateToFloat64(`x twice`), 6)
+	treeNodes.RemoveMethod(treeNodes.NUMBER_OBJ, "twice")
+	evaluator.SetNumberVar("x", 4)
+	_, err := evaluator.Evaluate(`x twice`)
+	_, ok := err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func BenchmarkBindingEvaluation(b *testing.B) {
+	evaluator := NewSmalltalkVM()
+	evaluator.SetNumberVar("x", 0)
+	binding := `((x * 2 + 1) max: 10) > 20 ifTrue: [x sqrt] ifFalse: [x negated abs]`
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		evaluator.SetNumberVar("x", float64(i%100))
+		evaluator.EvaluateToFloat64(binding)
+	}
+}
+
+func TestStringMessagesEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetStringVar("label", "  Speed ")
+	vm.SetNumberVar("speed", 42.5)
+
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`label trimBoth, ': ', 'km/h'`), "Speed: km/h")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'{1} is {2} km/h' format: #('speed' 42.5)`), "speed is 42.5 km/h")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'\{1} {1}' format: #(true)`), "{1} true")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`'привет' size`)), 6)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`('привет' at: 2) asString`), "р")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`('abc' at: 1) = $a`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToInterface(`'abc' at: 3`) == treeNodes.Character('c'))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'hello world' copyFrom: 7 to: 11`), "world")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'hello' copyFrom: 6 to: 5`), "")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`'hello world' indexOf: 'o'`)), 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`'hello world' indexOf: 'x'`)), 0)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`('hello world' indexOf: 'x') printString`), "0")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`'hello world' includesSubstring: 'lo w'`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`('hello' beginsWith: 'he') & ('hello' endsWith: 'llo')`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'Hello' asUppercase, 'Hello' asLowercase`), "HELLOhello")
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`' 12.5 ' asNumber + 1`), 13.5)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToInterface(`'twelve' asNumber`) == nil)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`'abc' = 'abc'`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`'abc' = 3`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`('abc' < 'abd') & ('b' > 'abc')`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`'' isEmpty & 'a' notEmpty`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'a-b-c' replaceAll: '-' with: ', '`), "a, b, c")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'stressed' reversed`), "desserts")
+
+	resultArray := vm.EvaluateToInterface(`'a, b,,c' substrings: ', '`).([]interface{})
+	testutils.ASSERT_EQ(t, len(resultArray), 3)
+	testutils.ASSERT_STREQ(t, resultArray[2].(string), "c")
+	resultArray = vm.EvaluateToInterface(`' one  two ' substrings`).([]interface{})
+	testutils.ASSERT_EQ(t, len(resultArray), 2)
+
+	_, err := vm.Evaluate(`'abc' at: 4`)
+	_, ok := err.(*treeNodes.SubscriptOutOfBoundsError)
+	testutils.ASSERT_TRUE(t, ok)
+	_, err = vm.Evaluate(`'{3}' format: #(1 2)`)
+	_, ok = err.(*treeNodes.SubscriptOutOfBoundsError)
+	testutils.ASSERT_TRUE(t, ok)
+	_, err = vm.Evaluate(`'abc', 3`)
+	_, ok = err.(*treeNodes.TypeMismatchError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestCommentsEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetNumberVar("speed", 30)
+	inputString := `"convert the speed"
+	|ms|
+	ms := speed / 3.6. "km/h to m/s"
+	ms rounded "whole meters"`
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(inputString)), 8)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToInterface(`"nothing but a comment"`) == nil)
+}
+
+func TestNilEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetVar("target", nil)
+	vm.SetNumberVar("speed", 12)
+
+	resultObject, err := vm.Evaluate(`nil`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.UNDEFINED_OBJ)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`nil isNil & target isNil & speed notNil`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`speed isNil | nil notNil`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(nil = nil) & (nil ~= 0) & (0 ~= nil) & ('' ~= nil) & (true ~= nil)`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`speed = nil`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`nil printString`), "nil")
+
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`target ifNil: [0]`)), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`speed ifNil: [0]`)), 12)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToInterface(`target ifNotNil: [:value | value + 1]`) == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`speed ifNotNil: [:value | value + 1]`)), 13)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`speed ifNotNil: [1]`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`target ifNil: [-1] ifNotNil: [:value | value * 2]`)), -1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`speed ifNil: [-1] ifNotNil: [:value | value * 2]`)), 24)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`'label' ifNotNil: [:value | value size] ifNil: [0]`)), 5)
+
+	resultArray := vm.EvaluateToInterface(`#(1 nil)`).([]interface{})
+	testutils.ASSERT_TRUE(t, resultArray[1] == nil)
+
+	_, err = vm.Evaluate(`nil + 1`)
+	_, ok := err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestSymbolEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	resultObject, err := vm.Evaluate(`#at:put:`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_TRUE(t, resultObject.TypeOf() == treeNodes.SYMBOL_OBJ)
+	testutils.ASSERT_TRUE(t, resultObject == treeNodes.NewSmalltalkSymbol("at:put:"))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`#foo printString`), "#foo")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(#foo == #foo) & (#foo = 'foo' asSymbol) & (#foo ~= 'foo') & (#foo ~~ #bar)`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`#foo asString = 'foo'`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#at:put: numArgs + #+ numArgs + #abs numArgs`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#abs value: -3`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#'max:' value: 3 value: 7`)), 7)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToInterface(`#(#red #green) at: 2`).(string), "green")
+}
+
+func TestPerformEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetStringVar("operation", "max:")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`-3 perform: #abs`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`3 perform: #+ with: 4`)), 7)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`3 perform: operation with: 4`)), 4)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`true perform: #ifTrue:ifFalse: with: [1] with: [2]`)), 1)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'hello' perform: #copyFrom:to: withArguments: #(2 3)`), "el")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(3 respondsTo: #sqrt) & (3 respondsTo: #isNil) & (nil respondsTo: #ifNil:)`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`'abc' respondsTo: #sqrt`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(Error new respondsTo: #signal) & (Error new respondsTo: #messageText)`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(Object respondsTo: #subclass:) & (Error respondsTo: #new)`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`(Error new respondsTo: #sqrt) | (Object respondsTo: #sqrt)`))
+
+	_, err := vm.Evaluate(`3 perform: #+`)
+	wrongCount, ok := err.(*treeNodes.WrongArgumentCountError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, wrongCount.Selector, "+")
+	_, err = vm.Evaluate(`3 perform: #max: with: 1 with: 2`)
+	wrongCount, ok = err.(*treeNodes.WrongArgumentCountError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_EQ(t, wrongCount.Actual, 2)
+	_, err = vm.Evaluate(`3 perform: #foo`)
+	_, ok = err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	_, err = vm.Evaluate(`3 perform: 4`)
+	_, ok = err.(*treeNodes.TypeMismatchError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestBlockProtocolEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[:a :b | a - b] value: 5 value: 3`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[:a :b :c | a + b * c] value: 1 value: 2 value: 3`)), 9)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[:a :b :c :d | a + b + c + d] value: 1 value: 2 value: 3 value: 4`)), 10)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[:a :b | a max: b] valueWithArguments: #(4 9)`)), 9)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[:a :b | a] numArgs + [] numArgs`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`([:a | a * 2] cull: 4) + ([7] cull: 4)`)), 15)
+
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|i sum| i := 0. sum := 0. [i < 5] whileTrue: [i := i + 1. sum := sum + i]. sum`)), 15)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|i| i := 10. [i <= 0] whileFalse: [i := i - 3]. i`)), -2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|i| i := 0. [i := i + 1. i < 3] whileTrue. i`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|i| i := 0. [i := i + 1. i >= 4 ifTrue: [^i * 10]] repeat`)), 40)
+
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|log| log := 0. [1] ensure: [log := 5]. log`)), 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|log| log := 0. [[^1] ensure: [log := 5]] value. log`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|log| log := 0. [1] ifCurtailed: [log := 5]. log`)), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|log| log := 0. [nil foo] ifCurtailed: [log := 5]. log`)), 0)
+	_, err := vm.Evaluate(`[nil foo] ensure: [1]`)
+	_, ok := err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	// an error of the ensure: or ifCurtailed: block is kept with the error of the receiver
+	for _, source := range []string{`[nil foo] ensure: [nil bar]`, `[nil foo] ifCurtailed: [nil bar]`} {
+		_, err = vm.Evaluate(source)
+		joined, ok := err.(*treeNodes.JoinedError)
+		testutils.ASSERT_TRUE(t, ok)
+		testutils.ASSERT_EQ(t, len(joined.Errors), 2)
+		testutils.ASSERT_STREQ(t, joined.Errors[0].(*treeNodes.DoesNotUnderstandError).Selector, "foo")
+		testutils.ASSERT_STREQ(t, joined.Errors[1].(*treeNodes.DoesNotUnderstandError).Selector, "bar")
+	}
+	_, err = vm.Evaluate(`[[^1] ifCurtailed: [nil bar]] value`)
+	_, ok = err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[[nil foo] ensure: [nil bar]] on: Error do: [:e | 7]`)), 7)
+
+	_, err = vm.Evaluate(`[:a :b | a + b] value: 1`)
+	wrongCount, ok := err.(*treeNodes.WrongArgumentCountError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, wrongCount.Selector, "value:")
+	testutils.ASSERT_EQ(t, wrongCount.Expected, 2)
+	_, err = vm.Evaluate(`[:a | a] value`)
+	_, ok = err.(*treeNodes.WrongArgumentCountError)
+	testutils.ASSERT_TRUE(t, ok)
+	_, err = vm.Evaluate(`[1] whileTrue: [2]`)
+	mustBeBoolean, ok := err.(*treeNodes.MustBeBooleanError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, mustBeBoolean.Selector, "whileTrue:")
+	testutils.ASSERT_STREQ(t, mustBeBoolean.Actual, treeNodes.NUMBER_OBJ)
+	_, err = vm.Evaluate(`[nil] whileFalse`)
+	_, ok = err.(*treeNodes.MustBeBooleanError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestBlockScopeEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetNumberVar("x", 11)
+	// block temporaries are fresh in every activation
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|b| b := [:a | |t| t isNil ifTrue: [t := a]. t]. (b value: 1) + (b value: 2)`)), 3)
+	// blocks change variables of the scope they were created in
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|count| count := 0. #(1 2 3) at: 1. [:v | count := count + v] value: 4. count`)), 4)
+	// but not the bindings of the evaluator
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`x := 5. x`)), 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`x`)), 11)
+}
+
+func TestNumericLoopsEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|sum| sum := 0. 1 to: 5 do: [:i | sum := sum + i]. sum`)), 15)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|sum| sum := 0. 10 to: 1 by: -3 do: [:i | sum := sum + i]. sum`)), 22)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|sum| sum := 0. 5 to: 1 do: [:i | sum := sum + i]. sum`)), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|n| n := 1. 4 timesRepeat: [n := n * 2]. n`)), 16)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`3 between: 1 and: 3`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`0 between: 1 and: 3`))
+	_, err := vm.EvaluateToInt64E(`1 to: 5 by: 0 do: [:i | i]`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestIntervalEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(1 to: 10) size`)), 10)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(1 to: 10 by: 2) last`)), 9)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(10 to: 1 by: -2) first`)), 10)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(1 to: 0) size`)), 0)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`(0 to: 1 by: 0.25) last`), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(1 to: 4) inject: 0 into: [:a :b | a + b]`)), 10)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`|sum| sum := 0. (1 to: 3) do: [:i | sum := sum + i]. sum`)), 6)
+	resultArray := vm.EvaluateToInterface(`(1 to: 4) collect: [:i | i * i]`).([]interface{})
+	testutils.ASSERT_EQ(t, len(resultArray), 4)
+	testutils.ASSERT_FLOAT64_EQ(t, resultArray[3].(float64), 16)
+	resultArray = vm.EvaluateToInterface(`(1 to: 10) select: [:i | i \\ 3 = 0]`).([]interface{})
+	testutils.ASSERT_EQ(t, len(resultArray), 3)
+	testutils.ASSERT_FLOAT64_EQ(t, resultArray[2].(float64), 9)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`((1 to: 3) asArray at: 2)`)), 2)
+	resultArray = vm.EvaluateToInterface(`3 to: 5`).([]interface{})
+	testutils.ASSERT_EQ(t, len(resultArray), 3)
+	_, err := vm.EvaluateToInt64E(`(1 to: 0) first`)
+	_, ok := err.(*treeNodes.SubscriptOutOfBoundsError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestIntegerEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`3 isInteger`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`3 isFloat`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`3.0 isFloat`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(6 / 3) isInteger`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(7 / 2) isFraction`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`3 asFloat isFloat`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`3 = 3.0`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`3.7 asInteger`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`-7 \\ 2`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`-7 // 2`)), -4)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`-7 rem: 2`)), -1)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`5.5 \\ 2`), 1.5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`12 gcd: 18`)), 6)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`4 lcm: 6`)), 12)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`5 factorial`)), 120)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`12 bitAnd: 10`)), 8)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`12 bitOr: 3`)), 15)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`1 bitShift: 10`)), 1024)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`1024 bitShift: -3`)), 128)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`255 printString: 16`), "FF")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`5 printString: 2`), "101")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`2.0 printString`), "2.0")
+
+	_, err := vm.EvaluateToInt64E(`1.5 factorial`)
+	_, ok := err.(*treeNodes.NotAnIntegerError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestLargeIntegerEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	// 2^53 + 1 can not be a float64
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`9007199254740992 + 1`)), 9007199254740993)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`25 factorial printString`), "15511210043330985984000000")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(9223372036854775807 + 1) printString`), "9223372036854775808")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(1 bitShift: 100) printString: 16`), "10000000000000000000000000")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(9223372036854775807 + 1) - 1 = 9223372036854775807`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`100 factorial / 98 factorial = 9900`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(-9223372036854775807 - 2) printString`), "-9223372036854775809")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToExactInterface(`9007199254740993`) == int64(9007199254740993))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToExactInterface(`1.5`) == 1.5)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToInterface(`9007199254740993`) == float64(9007199254740993))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToExactInterface(`3 -4`) == int64(-1))
+	large := vm.EvaluateToInterface(`30 factorial`).(*big.Int)
+	testutils.ASSERT_STREQ(t, large.String(), "265252859812191058636308480000000")
+
+	_, err := vm.EvaluateToInt64E(`30 factorial`)
+	_, ok := err.(*treeNodes.NotAnIntegerError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestEvaluateToInt64Error(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetNumberVar("speed", 42)
+	vm.SetIntegerVar("count", 9007199254740993)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`speed + 1`)), 43)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`count`)), 9007199254740993)
+	_, err := vm.EvaluateToInt64E(`speed / 5`)
+	notAnInteger, ok := err.(*treeNodes.NotAnIntegerError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, notAnInteger.Number, "8.4")
+}
+
+func TestFractionEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(1 / 3) printString`), "(1/3)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`((1 / 3) + (1 / 6)) printString`), "(1/2)")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(1 / 3) + (2 / 3) = 1`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`((1 / 3) + (2 / 3)) isInteger`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`((1 / 3) + 0.5) isFloat`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(1 / 3) < (1 / 2)`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(6 / 4) numerator`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(6 / 4) denominator`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(7 / 2) rounded`)), 4)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(-7 / 2) rounded`)), -4)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(-7 / 2) truncated`)), -3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(-7 / 2) floor`)), -4)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(7 / 2) ceiling`)), 4)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`((7 / 2) \\ 1) printString`), "(1/2)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'{1}' format: #(1)`), "1")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToRat(`(1 / 3) * 3 / 4`).String(), "1/4")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToRat(`0.1`).String(), "1/10")
+
+	_, err := vm.EvaluateToInt64E(`1 / 3`)
+	_, ok := err.(*treeNodes.NotAnIntegerError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+func TestScaledDecimalEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`3.14s2 printString`), "3.14s2")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(0.1s1 + 0.2s1) printString`), "0.3s1")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`0.1s1 + 0.2s1 = 0.3s1`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(19.99s2 * 3) printString`), "59.97s2")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(1.5s1 + 0.25s2) printString`), "1.75s2")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(10.00s2 / 3) printString`), "3.33s2")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`((1 / 3) + 1.00s2) printString`), "1.33s2")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(1.00s2 + 0.5) isFloat`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(3.14159s5 roundTo: 0.01s2) printString`), "3.14s2")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(17 roundTo: 5) printString`), "15")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`((2 / 3) asScaledDecimal: 2) printString`), "0.67s2")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(2 - 3.14s2) printString`), "-1.14s2")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'{1} EUR' format: #(12.50s2)`), "12.50 EUR")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToRat(`10.00s2 / 3`).String(), "10/3")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToRat(`0.1s1 * 3`).String(), "3/10")
+}
+
+func TestRadixAndExponentEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`16rFF`)), 255)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`2r1010 + 1`)), 11)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`-16r1F`)), -31)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`3 - 16r1F`)), -28)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`1e-3`), 0.001)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`2r1.1`), 1.5)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`16rFF printString: 2`), "11111111")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`1e3 isInteger`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`1e-3 isFloat`))
+}
+
+func TestCharacterEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`$a asInteger`)), 97)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`$a printString`), "$a")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`$a asUppercase printString`), "$A")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(Character value: 65) printString`), "$A")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`97 asCharacter asString`), "a")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`$e isVowel`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`$x isVowel`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`$7 isDigit`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`$7 isLetter`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`$z isLetter`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`$a < $b`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`$a == (Character value: 97)`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`$  asInteger = 32`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToInterface(`$a`) == treeNodes.Character('a'))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'{1}{2}' format: #($a $b)`), "ab")
+
+	_, err := vm.Evaluate(`Character value: -1`)
+	testutils.ASSERT_TRUE(t, err != nil)
+
+	// a variable hides the global with the same name
+	vm.SetNumberVar(`Character`, 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Character + 1`)), 6)
+}
+
+func TestLiteralArrayEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	resultArray := vm.EvaluateToInterface(`#(1 $a #foo 'str' true nil (1 2) #[1 2 3])`).([]interface{})
+	testutils.ASSERT_EQ(t, len(resultArray), 8)
+	testutils.ASSERT_FLOAT64_EQ(t, resultArray[0].(float64), 1)
+	testutils.ASSERT_TRUE(t, resultArray[1] == treeNodes.Character('a'))
+	testutils.ASSERT_STREQ(t, resultArray[2].(string), "foo")
+	testutils.ASSERT_STREQ(t, resultArray[3].(string), "str")
+	testutils.ASSERT_TRUE(t, resultArray[4].(bool))
+	testutils.ASSERT_TRUE(t, resultArray[5] == nil)
+	testutils.ASSERT_EQ(t, len(resultArray[6].([]interface{})), 2)
+	testutils.ASSERT_TRUE(t, bytes.Equal(resultArray[7].([]byte), []byte{1, 2, 3}))
+
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(#(foo at:put: + #bar) at: 1) == #foo`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(foo at:put: + #bar) at: 2) printString`), "#at:put:")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(#(foo at:put: + #bar) at: 3) numArgs`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`((#(1 (2 (3 4))) at: 2) at: 2) at: 1`)), 3)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'{1}' format: #(#(1 -2 foo))`), "#(1 -2 foo)")
+}
+
+func TestByteArrayEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_TRUE(t, bytes.Equal(vm.EvaluateToInterface(`#[1 2 255]`).([]byte), []byte{1, 2, 255}))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#[1 2 255] size`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#[1 2 255] last`)), 255)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| bytes | bytes := #[1 2 3]. bytes at: 2 put: 20. bytes at: 2`)), 20)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`#[1 2 255] printString`), "#[1 2 255]")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`#[104 105] asString`), "hi")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`#[1 2] = #[1 2]`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`#[1 2] includes: 2`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(#[1 2 3] collect: [:each | each * 2]) at: 3`)), 6)
+
+	_, err := vm.Evaluate(`#[1 2] at: 1 put: 256`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`#[1 2] at: 3`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestDynamicArrayEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	vm.SetNumberVar(`x`, 1)
+	vm.SetNumberVar(`y`, 2)
+	resultArray := vm.EvaluateToInterface(`{x. y. x + y}`).([]interface{})
+	testutils.ASSERT_EQ(t, len(resultArray), 3)
+	testutils.ASSERT_FLOAT64_EQ(t, resultArray[2].(float64), 3)
+
+	// the cached result is dropped when a variable of the array changes
+	vm.SetNumberVar(`x`, 10)
+	resultArray = vm.EvaluateToInterface(`{x. y. x + y}`).([]interface{})
+	testutils.ASSERT_FLOAT64_EQ(t, resultArray[0].(float64), 10)
+	testutils.ASSERT_FLOAT64_EQ(t, resultArray[2].(float64), 12)
+
+	testutils.ASSERT_EQ(t, len(vm.EvaluateToInterface(`{}`).([]interface{})), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`({x. {y. 'point'}} at: 2) at: 1`)), 2)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`'{1}' format: {#(1 2)}`), "#(1 2)")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| a | a := 3. ({a. [:b | b * 2] value: a} at: 2)`)), 6)
+
+	_, err := vm.Evaluate(`{x. undefinedVariable}`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestMutatedCollectionEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	_, err := vm.SetSliceVar(`arr`, []interface{}{1, 2})
+	testutils.ASSERT_TRUE(t, err == nil)
+	// every evaluation changes the array, so the result is never cached
+	for i := 2; i <= 4; i++ {
+		testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`arr at: 1 put: (arr at: 1) + 1`)), i)
+		testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`arr at: 1`)), i)
+	}
+	// the same for other collections
+	for _, collection := range []string{`OrderedCollection new`, `Set new`, `Bag new`} {
+		vm.SetVar(`items`, vm.RunProgram(collection))
+		for i := 1; i <= 3; i++ {
+			vm.Evaluate(`items add: items size`)
+			testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`items size`)), i)
+		}
+	}
+	vm.SetVar(`dictionary`, vm.RunProgram(`Dictionary new`))
+	for i := 1; i <= 3; i++ {
+		vm.Evaluate(`dictionary at: dictionary size put: 0`)
+		testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`dictionary size`)), i)
+	}
+	vm.Evaluate(`dictionary removeKey: 0`)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`dictionary size`)), 2)
+	vm.SetVar(`bytes`, vm.RunProgram(`#[1 2]`))
+	for i := 2; i <= 3; i++ {
+		vm.Evaluate(`bytes at: 2 put: (bytes at: 2) + 1`)
+		testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`bytes at: 2`)), i+1)
+	}
+}
+
+func TestOrderedCollectionEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| c | c := OrderedCollection new. c add: 3; add: 1; addFirst: 2. c printString`), "an OrderedCollection(2 3 1)")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(OrderedCollection with: 1 with: 2 with: 3) size`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| c | c := OrderedCollection withAll: #(1 2 3). c remove: 2. c inject: 0 into: [:sum :each | sum + each]`)), 4)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| c | c := OrderedCollection withAll: #(1 2 3). c at: 2 put: 5. c at: 2`)), 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(OrderedCollection new) at: 5 ifAbsent: [7]`)), 7)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`((OrderedCollection withAll: #(1 2 3 4)) select: [:each | each \\ 2 = 0]) printString`), "an OrderedCollection(2 4)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`((1 to: 4) reject: [:each | each \\ 2 = 0]) printString`), "#(1 3)")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 5 8) detect: [:each | each > 4] ifNone: [0]`)), 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 5 8) detect: [:each | each > 10] ifNone: [0]`)), 0)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`#(1 5 8) anySatisfy: [:each | each > 7]`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`#(1 5 8) allSatisfy: [:each | each > 1]`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`#(1 5 8) includes: 5.0`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`#() isEmpty`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| sum | sum := 0. #(10 20) keysAndValuesDo: [:i :each | sum := sum + (i * each)]. sum`)), 50)
+
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(3 1 2) asSortedCollection: [:a :b | a >= b]) printString`), "a SortedCollection(3 2 1)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| c | c := #(3 1 2) asSortedCollection. c add: 0. c printString`), "a SortedCollection(0 1 2 3)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| c | c := #() asSortedCollection: [:a :b | a first <= b first]. c add: #(2 x); add: #(1 y); add: #(2 z); add: #(1 w). (c collect: [:each | each last]) printString`), "an OrderedCollection(y w x z)")
+	// an element which the sort block can not compare is not added
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| c | c := #(3 1 2) asSortedCollection. [c add: 'four'] on: Error do: [:e | nil]. c printString`), "a SortedCollection(1 2 3)")
+	_, err := vm.Evaluate(`#(3 1 2) asSortedCollection addFirst: 5`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`OrderedCollection new removeFirst`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`(OrderedCollection with: 1) at: 2`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+}
+
+func TestSetAndBagEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 2 2 3 1.0) asSet size`)), 3)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| s | s := Set new. s add: #a; add: #b; add: #a. s printString`), "a Set(a b)")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(Set with: 'x' with: 'y') includes: 'x'`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`| s | s := Set withAll: #(1 2). s remove: 1. s includes: 1`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(Set withAll: #(1 2 3)) remove: 4 ifAbsent: [0]`)), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`((Set withAll: #(1 2 3)) collect: [:each | each \\ 2]) size`)), 2)
+	// arrays are equal when their elements are
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(#(1 2) = #(1 2.0)) & (#(1 (2 $a)) = #(1 (2 $a)))`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`(#(1 2) = #(2 1)) | (#(1) = #('1')) | (#(1 2) = (OrderedCollection with: 1 with: 2))`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`#((1 2) (3)) asSet includes: #(1 2)`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| d | d := Dictionary new. d at: #(1 2) put: 5. d at: #(1 2)`)), 5)
+
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 2 2 3 2) asBag occurrencesOf: 2`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| b | b := Bag new. b add: #x withOccurrences: 3. b remove: #x. b size`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 2 2 3 2) asBag asSet size`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 2 2) asBag inject: 0 into: [:sum :each | sum + each]`)), 5)
+	_, err := vm.Evaluate(`Bag new remove: 1`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestDictionaryEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| d | d := Dictionary new. d at: #a put: 1; at: #b put: 2. (d at: #a) + (d at: #b)`)), 3)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| d | d := Dictionary new. d at: 1 put: 'one'; at: 2 put: 'two'. d printString`), "a Dictionary(1->one 2->two)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| d | d := Dictionary new. d at: 1 put: 'one'. d at: 1.0`), "one")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Dictionary new at: #missing ifAbsent: [5]`)), 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| d | d := Dictionary new. d at: #a ifAbsentPut: [4]. d at: #a ifAbsentPut: [5]`)), 4)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`| d | d := Dictionary new. d at: #a put: 1. d includesKey: #a`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`| d | d := Dictionary new. d at: #a put: 1. d removeKey: #a. d includesKey: #a`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| d | d := Dictionary new. d at: #a put: 1; at: #b put: 2. (d keyAtValue: 2) asString`), "b")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| d | d := Dictionary new. d at: #a put: 1; at: #b put: 2. d inject: 0 into: [:sum :each | sum + each]`)), 3)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| d | d := Dictionary new. d at: #a put: 1; at: #b put: 2. ((d select: [:each | each > 1]) collect: [:each | each * 10]) printString`), "a Dictionary(b->20)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| d s | d := Dictionary new. d at: #a put: 1; at: #b put: 2. s := ''. d keysAndValuesDo: [:k :v | s := s , k asString , v printString]. s`), "a1b2")
+
+	_, err := vm.Evaluate(`Dictionary new at: #missing`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestCollectionGoConversion(t *testing.T) {
+	vm := NewSmalltalkVM()
+	values := vm.EvaluateToSlice(`(OrderedCollection new) add: 1; add: 'two'; add: #(3); yourself`)
+	testutils.ASSERT_EQ(t, len(values), 3)
+	testutils.ASSERT_FLOAT64_EQ(t, values[0].(float64), 1)
+	testutils.ASSERT_STREQ(t, values[1].(string), "two")
+	testutils.ASSERT_FLOAT64_EQ(t, values[2].([]interface{})[0].(float64), 3)
+	testutils.ASSERT_EQ(t, len(vm.EvaluateToSlice(`1 to: 5`)), 5)
+	_, err := vm.EvaluateToSliceE(`42`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.TypeMismatchError)))
+
+	result := vm.EvaluateToMap(`| d | d := Dictionary new. d at: #name put: 'gotalk'; at: 'size' put: 3. d`)
+	testutils.ASSERT_STREQ(t, result["name"].(string), "gotalk")
+	testutils.ASSERT_FLOAT64_EQ(t, result["size"].(float64), 3)
+	_, err = vm.EvaluateToMapE(`| d | d := Dictionary new. d at: 1 put: #number; at: '1' put: #string. d`)
+	testutils.ASSERT_STREQ(t, err.Error(), `the NUMBER key and the STRING key of the dictionary are both "1" in Go`)
+
+	_, err = vm.SetSliceVar(`items`, []interface{}{1, 2.5, "three", []interface{}{true}})
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`items size`)), 4)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`(items at: 1) + (items at: 2)`), 3.5)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(items at: 4) at: 1`))
+
+	// a rune is an int32, so only treeNodes.Character becomes a Character
+	_, err = vm.SetSliceVar(`codes`, []interface{}{'a', treeNodes.Character('a')})
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`((codes at: 1) = 97) & ((codes at: 2) = $a)`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToSlice(`codes`)[1] == treeNodes.Character('a'))
+
+	_, err = vm.SetMapVar(`settings`, map[string]interface{}{"limit": 10, "names": []string{"a", "b"}})
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(settings at: 'limit') + (settings at: 'names') size`)), 12)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`settings keys printString`), "#(limit names)")
+
+	_, err = vm.SetSliceVar(`channels`, []interface{}{make(chan int)})
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestArrayProtocolEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| a | a := #(1 2 3). a at: 2 put: 20. a at: 2`)), 20)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 2 3) size`)), 3)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(4 5 6) first + #(4 5 6) last`)), 10)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(4 5 6) indexOf: 5`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(4 5 6) indexOf: 7`)), 0)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 2) copyWith: 3) printString`), "#(1 2 3)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 2) , #(3 4)) printString`), "#(1 2 3 4)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`#(1 2 3) reverse printString`), "#(3 2 1)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`#(3 1 2) sort printString`), "#(1 2 3)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| a | a := #('pear' 'fig' 'apple'). a sort: [:x :y | x size <= y size]. a printString`), "#(fig pear apple)")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(3 9 2) max`)), 9)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(3 9 2) min`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(3 9 2) sum`)), 14)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`#(1 2 3 4) average asFloat`), 2.5)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 2 3) with: #(10 20 30) collect: [:a :b | a + b]) printString`), "#(11 22 33)")
+
+	_, err := vm.Evaluate(`#(1 2) at: 5`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+	_, err = vm.Evaluate(`#(1 2) at: 1.5`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+	_, err = vm.Evaluate(`#(1 2) at: 'one'`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+	_, err = vm.Evaluate(`#(1 2) at: 0 put: 3`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+	_, err = vm.Evaluate(`#() first`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+	_, err = vm.Evaluate(`#() max`)
+	var empty *treeNodes.EmptyCollectionError
+	testutils.ASSERT_TRUE(t, errors.As(err, &empty))
+	testutils.ASSERT_STREQ(t, err.Error(), "CollectionIsEmpty: #max needs an element of the empty ARRAY at 5")
+	for _, code := range []string{`#() sum`, `#() average`, `#() min`, `OrderedCollection new removeFirst`, `OrderedCollection new removeLast`} {
+		_, err = vm.Evaluate(code)
+		testutils.ASSERT_TRUE(t, errors.As(err, &empty))
+	}
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[#() sum] on: CollectionIsEmpty do: [:e | 0]`)), 0)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`CollectionIsEmpty inheritsFrom: Error`))
+	_, err = vm.Evaluate(`#(1 2) with: #(1) collect: [:a :b | a]`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestCyclicCollectionEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| a | a := #(1 2). a at: 1 put: a. a printString`), "#(... 2)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| a | a := #(1). a at: 1 put: {a. a}. a printString`), "#(#(... ...))")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| c d | c := OrderedCollection new. d := Dictionary new. c add: d. d at: #c put: c. c printString`), "an OrderedCollection(a Dictionary(c->...))")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`| a b | a := #(1). b := #(1). a at: 1 put: a. b at: 1 put: b. a = b`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`| a | a := #(1). a at: 1 put: a. (Set with: a with: a) size = 1`))
+
+	_, err := vm.EvaluateToInterfaceE(`| a | a := #(1). a at: 1 put: a. a`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.EvaluateToMapE(`| d | d := Dictionary new. d at: #self put: d. d`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`| a | a := #(1). a at: 1 put: a. a + 1`)
+	testutils.ASSERT_STREQ(t, err.Error(), "arrays which contain themselves can not be combined elementwise")
+	_, err = vm.Evaluate(`| a | a := #(1). a at: 1 put: a. 1 + a`)
+	testutils.ASSERT_TRUE(t, err != nil)
+
+	// an array which is an element twice is not a cycle
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| a | a := #(1 2). {a. a} printString`), "#(#(1 2) #(1 2))")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| a | a := #(1 2). ({a. a} + 1) printString`), "#(#(2 3) #(2 3))")
+	testutils.ASSERT_EQ(t, len(vm.EvaluateToInterface(`| a | a := #(1 2). {a. a}`).([]interface{})), 2)
+}
+
+func TestElementwiseArrayEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 2 3) + #(10 20 30)) printString`), "#(11 22 33)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 2 3) * 2) printString`), "#(2 4 6)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(6 8) / #(2 4)) printString`), "#(3 2)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#((1 2) (3 4)) * 10) printString`), "#(#(10 20) #(30 40))")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#((1 2) (3 4)) + #(1 2)) printString`), "#(#(2 3) #(5 6))")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 2) + #((1 2) 3)) printString`), "#(#(2 3) 5)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 5 3) > 2) printString`), "#(false true true)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 5 3) <= #(1 4 4)) printString`), "#(true false true)")
+
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 2 3) dot: #(4 5 6)`)), 32)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`#(3 4) norm`), 5)
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`#(3 4) normalized first`), 0.6)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(#(1 2 3) * #(2 2 2)) sum`)), 12)
+
+	vm.SetSliceVar(`vertex`, []interface{}{1, 2})
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`((#((0 -1) (1 0)) collect: [:row | row dot: vertex]) + #(10 10)) printString`), "#(8 11)")
+
+	_, err := vm.Evaluate(`#(1 2 3) + #(1 2)`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`#(1 2) dot: #(1 2 3)`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`#(1 'two') * 2`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`#(1 2) + 'three'`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestBooleanProtocolEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`true and: [true] and: [true]`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`true and: [false] and: [3]`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`false or: [false] or: [true]`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`true or: [3] or: [4]`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`true and: false`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`false or: true`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`false eqv: false`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`true eqv: false`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`true xor: [false]`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`true printString`), "true")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`true asBit + false asBit`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`true ifTrue: 1 ifFalse: 2`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`false ifTrue: 1 ifFalse: 2`)), 2)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(3 > 2) ifTrue: 'yes'`), "yes")
+
+	var mustBeBoolean *treeNodes.MustBeBooleanError
+	_, err := vm.Evaluate(`true and: [3]`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &mustBeBoolean))
+	testutils.ASSERT_STREQ(t, mustBeBoolean.Selector, "and:")
+	testutils.ASSERT_STREQ(t, mustBeBoolean.Actual, treeNodes.NUMBER_OBJ)
+	_, err = vm.Evaluate(`false or: 'yes'`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &mustBeBoolean))
+	_, err = vm.Evaluate(`true & 3`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &mustBeBoolean))
+	_, err = vm.Evaluate(`false | nil`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &mustBeBoolean))
+	_, err = vm.Evaluate(`true eqv: 1`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &mustBeBoolean))
+
+	var nonBoolean *treeNodes.NonBooleanReceiverError
+	_, err = vm.Evaluate(`3 ifTrue: [4]`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &nonBoolean))
+	testutils.ASSERT_STREQ(t, nonBoolean.Selector, "ifTrue:")
+	testutils.ASSERT_STREQ(t, nonBoolean.ReceiverType, treeNodes.NUMBER_OBJ)
+	_, err = vm.Evaluate(`nil and: [true]`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &nonBoolean))
+	_, err = vm.Evaluate(`'text' foo`)
+	testutils.ASSERT_FALSE(t, errors.As(err, &nonBoolean))
+}
+
+func TestExceptionEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[ZeroDivide signal] on: ZeroDivide do: [:e | e return: 0]`)), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[ZeroDivide new signal. 5] on: Error do: [:e | 7]`)), 7)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[Error signal: 'boom'] on: Error do: [:e | e messageText]`), "boom")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[Error new signal: 'boom'] on: Error do: [:e | e messageText]`), "boom")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[Warning signal] on: Warning do: [:e | e messageText]`), "Warning")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[Error signal] on: Exception do: [:e | e printString]`), "an Error")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`([Error signal: 'x'] on: Error do: [:e | e return]) isNil`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[3 + 4] on: Error do: [:e | 0]`)), 7)
+
+	// errors of primitives are exceptions
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[#(1 2) at: 5] on: SubscriptOutOfBounds do: [:e | -1]`)), -1)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`[3 foo] on: MessageNotUnderstood do: [:e | e class == MessageNotUnderstood]`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[3 + 'four'] on: Error do: [:e | 0]`)), 0)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[#(1) at: 2] on: Error do: [:e | e messageText]`), "SubscriptOutOfBounds: index 2 is out of bounds 1 to 1")
+
+	// retry evaluates the protected block again
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| tries | tries := 0. [tries := tries + 1. tries < 3 ifTrue: [Error signal]. tries] on: Error do: [:e | e retry]`)), 3)
+
+	// pass and signal hand the exception to an outer handler
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[[ZeroDivide signal] on: ZeroDivide do: [:e | e pass]] on: ArithmeticError do: [:e | 2]`)), 2)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[[Error signal: 'inner'] on: Error do: [:e | e signal: 'outer']] on: Error do: [:e | e messageText]`), "outer")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[[Warning signal] on: ZeroDivide do: [:e | 1]] on: Warning do: [:e | 2]`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[[Error signal] on: Error do: [:inner | [ZeroDivide signal] on: ZeroDivide do: [:e | inner return: 5]]] value`)), 5)
+
+	// ensure: and ifCurtailed: run when an exception unwinds through them
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| log | log := 0. [[Error signal] ensure: [log := log + 1]] on: Error do: [:e | 0]. log`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| log | log := 0. [[Error signal] ifCurtailed: [log := 2]] on: Error do: [:e | 0]. log`)), 2)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| log | log := 0. [[5] ifCurtailed: [log := 2]] on: Error do: [:e | 0]. log`)), 0)
+	// the handler runs where the exception is signalled, before the exception unwinds the protected block
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| log | log := ''. [[Error signal] ensure: [log := log , 'ensure']] on: Error do: [:e | log := log , 'handler ']. log`), "handler ensure")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`| log | log := ''. [[Error signal] ifCurtailed: [log := log , 'curtailed']] on: Error do: [:e | log := log , 'handler ']. log`), "handler curtailed")
+
+	// resume: makes the message which signalled the exception answer the value
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[(Warning signal: 'default?') + 1] on: Warning do: [:e | e resume: 41]`)), 42)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[(Exception signal) ifNil: [7] ifNotNil: [:x | x]] on: Exception do: [:e | e resume]`)), 7)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[[(Warning signal) * 2] on: Warning do: [:e | e pass]] on: Warning do: [:e | e resume: 4]`)), 8)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[[Error signal. 1] on: Error do: [:e | e resume: 5]] on: Error do: [:e | e messageText]`), "Error is not resumable")
+
+	// a Warning which no handler handles answers nil and the evaluation goes on
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| log | log := 0. (Warning signal: 'careful') isNil ifTrue: [log := 5]. log`)), 5)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[Warning signal. 3] on: ZeroDivide do: [:e | 0]`)), 3)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(Warning new perform: #signal) isNil`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[Warning signal. 3] on: Warning do: [:e | e pass]`)), 3)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`| w | w := Warning subclass: #Deprecation. (w signal: 'old') isNil`))
+	_, err := vm.Evaluate(`[Warning signal. 3] on: Warning do: [:e | Error signal]`)
+	testutils.ASSERT_TRUE(t, err != nil)
+
+	// a ^ inside of the protected block is not an exception
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[^ 4] on: Error do: [:e | 0]. 5`)), 4)
+
+	// stored blocks find the handlers around the place where they are evaluated
+	workspace := NewSmalltalkWorkspace()
+	workspace.RunProgram(`careful := [(Warning signal: 'careful') ifNil: [1] ifNotNil: [:x | x]]. failing := [:x | Error signal: 'failing']`)
+	testutils.ASSERT_EQ(t, int(workspace.EvaluateToInt64(`careful value`)), 1)
+	testutils.ASSERT_EQ(t, int(workspace.EvaluateToInt64(`[careful value] on: Warning do: [:e | e resume: 2]`)), 2)
+	testutils.ASSERT_EQ(t, int(workspace.EvaluateToInt64(`[[careful value] on: Warning do: [:e | e resume: 3]] value`)), 3)
+	testutils.ASSERT_EQ(t, int(workspace.EvaluateToInt64(`[failing value: 1] on: Error do: [:e | 4]`)), 4)
+	testutils.ASSERT_EQ(t, int(workspace.EvaluateToInt64(`[#(1 2) do: failing] on: Error do: [:e | 5]`)), 5)
+	testutils.ASSERT_EQ(t, int(workspace.EvaluateToInt64(`[careful value] on: ZeroDivide do: [:e | 6]`)), 1)
+
+	_, err = vm.Evaluate(`Error signal: 'unhandled'`)
+	var exception *treeNodes.SmalltalkException
+	testutils.ASSERT_TRUE(t, errors.As(err, &exception))
+	testutils.ASSERT_STREQ(t, exception.GetMessageText(), "unhandled")
+	testutils.ASSERT_STREQ(t, err.Error(), "Error: unhandled")
+
+	// an error which is not handled stays the error of the primitive
+	_, err = vm.Evaluate(`[#(1) at: 2] on: ZeroDivide do: [:e | 0]`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+	_, err = vm.Evaluate(`[#(1) at: 2] on: Error do: [:e | e pass]`)
+	testutils.ASSERT_TRUE(t, errors.As(err, new(*treeNodes.SubscriptOutOfBoundsError)))
+	_, err = vm.Evaluate(`[1] on: Character do: [:e | 0]`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestHandlersOfConcurrentEvaluations(t *testing.T) {
+	// evaluators which share the global scope do not see the handlers of each other
+	global := new(treeNodes.Scope).Initialize()
+	programs := []string{
+		`[(Warning signal) ifNil: [1] ifNotNil: [:x | x]] on: Warning do: [:e | e resume: 2]`,
+		`(Warning signal) ifNil: [1] ifNotNil: [:x | x]`,
+	}
+	failures := make([]int, 4)
+	var wait sync.WaitGroup
+	for i := range failures {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			vm := NewEvaluatorWithGlobalScope(global)
+			for j := 0; j < 200; j++ {
+				if vm.EvaluateToInt64(programs[i%2]) != int64(2-i%2) {
+					failures[i]++
+				}
+			}
+		}(i)
+	}
+	wait.Wait()
+	for _, each := range failures {
+		testutils.ASSERT_EQ(t, each, 0)
+	}
+}
+
+func TestNumericPolicyEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	// IEEE values by default
+	testutils.ASSERT_TRUE(t, math.IsInf(vm.EvaluateToFloat64(`1 / 0`), 1))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`-1 sqrt isNaN`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(1 / 0) isInfinite`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`(0 \\ 0) isFinite`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`2 sqrt isFinite`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`3 isNaN`))
+	// a division which a handler protects raises ZeroDivide like in Pharo
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`| x | x := 5. [x / 0] on: ZeroDivide do: [:e | e return: 0]`)), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[1 // 0] on: ArithmeticError do: [:e | -1]`)), -1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[-1 sqrt] on: DomainError do: [:e | 2]`)), 2)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`([1 / 0] on: MessageNotUnderstood do: [:e | 0]) isInfinite`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(([1] on: ZeroDivide do: [:e | 0]) / 0) isInfinite`))
+
+	vm.SetNumericPolicy(treeNodes.NumericPolicy{Mode: treeNodes.RaiseNumericErrors})
+	for _, code := range []string{`1 / 0`, `1 // 0`, `1 \\ 0`, `1 rem: 0`, `(1/2) / 0`, `1.5 / 0`, `#(1 2) / 0`} {
+		_, err := vm.Evaluate(code)
+		var zeroDivide *treeNodes.ZeroDivideError
+		testutils.ASSERT_TRUE(t, errors.As(err, &zeroDivide))
+	}
+	_, err := vm.Evaluate(`-1 sqrt`)
+	var domainError *treeNodes.DomainError
+	testutils.ASSERT_TRUE(t, errors.As(err, &domainError))
+	testutils.ASSERT_STREQ(t, err.Error(), "DomainError: #sqrt is not defined for -1 at 4")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[10 / 0] on: ZeroDivide do: [:e | -1]`)), -1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[2 arcSin] on: ArithmeticError do: [:e | e class == DomainError ifTrue: [1] ifFalse: [0]]`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`#(1 2 0) inject: 0 into: [:sum :each | sum + ([12 / each] on: ZeroDivide do: [:e | 0])]`)), 18)
+	// divisions by perform: and inside of other primitives follow the policy too
+	_, err = vm.Evaluate(`1 perform: #/ with: 0`)
+	testutils.ASSERT_STREQ(t, err.Error(), "ZeroDivide: #/ divides 1 by zero at 3")
+	_, err = vm.Evaluate(`#(0 0) normalized`)
+	testutils.ASSERT_STREQ(t, err.Error(), "ZeroDivide: #/ divides 0 by zero at 8")
+	_, err = vm.Evaluate(`-1 perform: #sqrt`)
+	testutils.ASSERT_TRUE(t, errors.As(err, &domainError))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[#(3 4) perform: #/ with: 0] on: ZeroDivide do: [:e | 5]`)), 5)
+	// infinities which are not caused by a division by zero stay infinities
+	testutils.ASSERT_TRUE(t, math.IsInf(vm.EvaluateToFloat64(`1e308 * 10`), 1))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(1e308 * 10) / 0 = (1e308 * 10)`))
+
+	// the policy is checked by every evaluation, so cached results are evaluated again
+	vm.SetNumericPolicy(treeNodes.NumericPolicy{Mode: treeNodes.NumericDefault, Default: treeNodes.NewSmalltalkInteger(0)})
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`1 / 0`)), 0)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`-4 sqrt + 1`)), 1)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(#(1 2) / #(0 2)) printString`), "#(0 1)")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`4 perform: #// with: 0`)), 0)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`#(0 0) normalized printString`), "#(0 0)")
+	vm.SetNumericPolicy(treeNodes.NumericPolicy{Mode: treeNodes.NumericDefault})
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(1 / 0) isNil`))
+}
+
+const gaugeDefinition = `Object subclass: #Gauge
+	instanceVariableNames: 'min max'
+	classVariableNames: 'Created'
+	package: 'Widgets'!
+
+!Gauge methodsFor: 'initialization'!
+initialize
+	min := 0.
+	max := 100.
+	Created := (Created ifNil: [0]) + 1
+!
+min: aMin max: aMax
+	min := aMin.
+	max := aMax
+! !
+
+!Gauge methodsFor: 'accessing'!
+span
+	^max - min
+!
+clamp: value
+	^(value max: min) min: max
+!
+firstOver: limit in: values
+	values do: [:each | each > limit ifTrue: [^each]].
+	^nil
+!
+printString
+	^'Gauge(' , min printString , '..' , max printString , ')'
+! !
+
+!Gauge class methodsFor: 'instance creation'!
+min: aMin max: aMax
+	^self new min: aMin max: aMax
+!
+created
+	^Created
+! !
+
+Gauge subclass: #PercentGauge instanceVariableNames: 'label'!
+
+!PercentGauge methodsFor: 'accessing'!
+span
+	^super span / 100
+!
+describe: value
+	^[:x | label , ' ' , (self clamp: x) printString] value: value
+!
+label: aString
+	label := aString
+! !
+
+!PercentGauge class methodsFor: 'instance creation'!
+new
+	^super new label: 'percent'
+! !
+`
+
+func TestClassDefinitionEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	err := vm.FileIn(gaugeDefinition)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(Gauge min: 10 max: 50) span`)), 40)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(Gauge new min: 10 max: 50; yourself) clamp: 70`)), 50)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`Gauge new printString`), "Gauge(0..100)")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`{Gauge new. 3} printString`), "#(Gauge(0..100) 3)")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Gauge new firstOver: 2 in: #(1 5 7)`)), 5)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(Gauge new firstOver: 9 in: #(1 5 7)) isNil`))
+
+	// inheritance, super and class side methods
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`PercentGauge new describe: 150`), "percent 100")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`(PercentGauge min: 0 max: 300) span`)), 3)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`PercentGauge superclass name`), "Gauge")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`PercentGauge new isKindOf: Gauge`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`PercentGauge inheritsFrom: Object`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`PercentGauge new respondsTo: #clamp:`))
+	testutils.ASSERT_FALSE(t, vm.EvaluateToBool(`Gauge new respondsTo: #label:`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToInt64(`Gauge created`) > 0)
+	_, err = vm.Evaluate(`Gauge new label: 'x'`)
+	dnu, ok := err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_STREQ(t, dnu.Selector, "label:")
+
+	// scripts define classes too, and later programs see them
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`Object subclass: #Point3 instanceVariableNames: 'x y z'. Point3 new printString`), "a Point3")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`Point3 name`), "Point3")
+	_, err = vm.Evaluate(`Object subclass: #point`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.Evaluate(`Gauge subclass: #WideGauge instanceVariableNames: 'max'`)
+	testutils.ASSERT_TRUE(t, err != nil)
+
+	// subclasses of exception classes are exceptions
+	err = vm.FileIn(`Error subclass: #GaugeError instanceVariableNames: 'gauge'!
+!GaugeError methodsFor: 'accessing'!
+gauge: aGauge
+	gauge := aGauge
+!
+messageText
+	^'bad ' , gauge printString
+! !`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[(GaugeError new gauge: Gauge new) signal] on: Error do: [:e | e messageText]`), "bad Gauge(0..100)")
+
+	// classes belong to the evaluator which defines them
+	_, err = NewSmalltalkVM().Evaluate(`Gauge new`)
+	_, ok = err.(*treeNodes.UndefinedVariableError)
+	testutils.ASSERT_TRUE(t, ok)
+
+	err = vm.FileIn("!Gauge methodsFor: 'broken'!\nspan ^max -! !")
+	testutils.ASSERT_TRUE(t, err != nil)
+
+	// built-in classes are shared by all evaluators, so their methods do not change
+	err = vm.FileIn("!Object methodsFor: 'leaking'!\nleak\n\t^1\n! !")
+	testutils.ASSERT_TRUE(t, err != nil)
+	testutils.ASSERT_STREQ(t, err.Error(), "chunk 3: Object is a built-in class, its methods can not be changed")
+	err = vm.FileIn("!Error class methodsFor: 'leaking'!\nleak\n\t^1\n! !")
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = NewSmalltalkVM().Evaluate(`Object new leak`)
+	_, ok = err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+
+	// perform: defines classes like a message expression
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(Object perform: #subclass: with: #Performed) name`), "Performed")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`Performed new isKindOf: Performed`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(Gauge perform: #subclass:instanceVariableNames: withArguments: #(#TallGauge 'height')) superclass name`), "Gauge")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`TallGauge new span`)), 100)
+}
+
+func TestClassSideEffectsEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	err := vm.FileIn(`Object subclass: #Counter instanceVariableNames: '' classVariableNames: 'Count' package: 'Test'!
+!Counter class methodsFor: 'counting'!
+next
+	Count := (Count ifNil: [0]) + 1.
+	^Count
+! !`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	// the same program answers a different result every time
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Counter next`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Counter next`)), 2)
+
+	err = vm.FileIn(gaugeDefinition)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Gauge new span`)), 100)
+	err = vm.FileIn("!Gauge methodsFor: 'accessing'!\nspan\n\t^max - min * 2\n! !")
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Gauge new span`)), 200)
+
+	// cached programs are evaluated again after a class definition
+	vm.SetVar("p", treeNodes.NewSmalltalkInteger(3))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`p + 1`)), 4)
+	program := vm.programCache[`p + 1`]
+	testutils.ASSERT_TRUE(t, program.GetLastValue() != nil)
+	vm.Evaluate(`Object subclass: #Later`)
+	testutils.ASSERT_TRUE(t, program.GetLastValue() == nil)
+}
+
+func TestFileInWhileEvaluating(t *testing.T) {
+	// evaluators which share the global scope send messages to a class while another one files in its methods
+	global := new(treeNodes.Scope).Initialize()
+	vm := NewEvaluatorWithGlobalScope(global)
+	meterMethods := "!Meter methodsFor: 'accessing'!\nlevel: aLevel\n\tlevel := aLevel\n!\nlevel\n\t^level\n! !\n" +
+		"!Meter class methodsFor: 'instance creation'!\nat: aLevel\n\t^self new level: aLevel\n! !"
+	err := vm.FileIn("Object subclass: #Meter instanceVariableNames: 'level'!\n" + meterMethods)
+	testutils.ASSERT_TRUE(t, err == nil)
+	failures := make([]int, 4)
+	var wait sync.WaitGroup
+	for i := range failures {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			other := NewEvaluatorWithGlobalScope(global)
+			for j := 0; j < 50; j++ {
+				if other.EvaluateToInt64(`(Meter at: 3) level`) != 3 {
+					failures[i]++
+				}
+			}
+		}(i)
+	}
+	for j := 0; j < 50; j++ {
+		err = vm.FileIn(meterMethods)
+		testutils.ASSERT_TRUE(t, err == nil)
+	}
+	wait.Wait()
+	for _, each := range failures {
+		testutils.ASSERT_EQ(t, each, 0)
+	}
+}
+
+func TestPseudoVariableEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	err := vm.FileIn(`Object subclass: #Probe instanceVariableNames: 'log'!
+!Probe methodsFor: 'diagnostics'!
+where
+	^thisContext printString
+!
+selectorAndClass
+	^{thisContext selector. thisContext methodClass name}
+!
+me
+	^#(1) collect: [:each | self]
+!
+contextInBlock
+	^[thisContext receiver == self] value
+!
+position
+	^thisContext position
+! !
+Probe subclass: #SubProbe!
+!SubProbe methodsFor: 'diagnostics'!
+where
+	^'sub ' , super where
+! !`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`Probe new where`), "Probe>>where")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`SubProbe new where`), "sub Probe>>where")
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`Probe new selectorAndClass printString`), "#(selectorAndClass Probe)")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`| p | p := Probe new. (p me at: 1) == p`))
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`Probe new contextInBlock`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`Probe new position`)), 13)
+
+	// programs are not methods: self is nil unless the host sets it
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`self isNil`))
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`thisContext printString`), "DoIt")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`thisContext selector isNil`))
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`3 + thisContext position`)), 8)
+	host := NewSmalltalkVM()
+	host.SetNumberVar("self", 42)
+	testutils.ASSERT_EQ(t, int(host.EvaluateToInt64(`self + thisContext receiver`)), 84)
+
+	_, err = vm.Evaluate(`self := 3`)
+	_, ok := err.(*parser.ParseError)
+	testutils.ASSERT_TRUE(t, ok)
+	_, err = vm.Evaluate(`thisContext foo`)
+	_, ok = err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+type testWidget struct {
+	Width    int
+	Height   float64
+	Title    string `smalltalk:"label"`
+	Secret   string `smalltalk:"-"`
+	Origin   testPoint
+	Tags     []string
+	Parent   *testWidget
+	URLPath  string
+	disabled bool
+}
+
+func (w *testWidget) Area() float64 {
+	return float64(w.Width) * w.Height
+}
+
+func (w *testWidget) Resize(width int, height float64) {
+	w.Width = width
+	w.Height = height
+}
+
+func (w *testWidget) Grow(by int) (int, error) {
+	if by < 0 {
+		return 0, errors.New("widgets do not shrink")
+	}
+	w.Width += by
+	return w.Width, nil
+}
+
+func (w *testWidget) Share(parts int) int {
+	return w.Width / parts
+}
+
+func (w *testWidget) Disable() {
+	w.disabled = true
+}
+
+func (w *testWidget) Corners() (testPoint, testPoint) {
+	return w.Origin, testPoint{w.Origin.X + w.Width, w.Origin.Y + int(w.Height)}
+}
+
+func (w *testWidget) SmalltalkSelectors() map[string]string {
+	return map[string]string{"Resize": "width:height:", "Disable": "-"}
+}
+
+func TestGoObjectEvaluation(t *testing.T) {
+	vm := NewSmalltalkVM()
+	widget := &testWidget{Width: 10, Height: 2.5, Title: "OK", Secret: "hidden", Origin: testPoint{1, 2}, Tags: []string{"a"}}
+	_, err := vm.SetGoObject(`widget`, widget)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`widget width`)), 10)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`widget label`), "OK")
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`widget area`), 25)
+
+	// setters answer the receiver and change the Go value
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`(widget width: 20; label: 'Cancel'; yourself) printString`), "a testWidget")
+	testutils.ASSERT_EQ(t, widget.Width, 20)
+	testutils.ASSERT_STREQ(t, widget.Title, "Cancel")
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`widget area`), 50)
+	widget.Height = 1
+	testutils.ASSERT_FLOAT64_EQ(t, vm.EvaluateToFloat64(`widget area`), 20)
+
+	// methods with renamed selectors, error results and several results
+	vm.Evaluate(`widget width: 3 height: 4`)
+	testutils.ASSERT_EQ(t, widget.Width, 3)
+	testutils.ASSERT_FLOAT64_EQ(t, widget.Height, 4)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`widget grow: 2`)), 5)
+	testutils.ASSERT_STREQ(t, vm.EvaluateToString(`[widget grow: -1] on: Error do: [:e | e messageText]`), "widgets do not shrink")
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`widget corners last x`)), 6)
+
+	// a panic of a method is an error which scripts handle
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`widget share: 5`)), 1)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`[widget share: 0] on: Error do: [:e | -1]`)), -1)
+	_, err = vm.Evaluate(`widget share: 0`)
+	var goPanic *treeNodes.GoPanicError
+	testutils.ASSERT_TRUE(t, errors.As(err, &goPanic))
+	testutils.ASSERT_STREQ(t, goPanic.Selector, "share:")
+
+	// struct fields are changed in place, slices and pointers are converted
+	vm.Evaluate(`widget origin x: 7`)
+	testutils.ASSERT_EQ(t, widget.Origin.X, 7)
+	vm.Evaluate(`widget tags: #('b' 'c')`)
+	testutils.ASSERT_EQ(t, len(widget.Tags), 2)
+	testutils.ASSERT_STREQ(t, widget.Tags[1], "c")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`widget parent isNil`))
+	parent := &testWidget{Width: 100}
+	vm.SetGoObject(`other`, parent)
+	vm.Evaluate(`widget parent: other`)
+	testutils.ASSERT_TRUE(t, widget.Parent == parent)
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`widget parent width`)), 100)
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`widget parent = other`))
+	vm.Evaluate(`widget urlPath: '/home'`)
+	testutils.ASSERT_STREQ(t, widget.URLPath, "/home")
+	testutils.ASSERT_TRUE(t, vm.EvaluateToBool(`(widget respondsTo: #label:) and: [(widget respondsTo: #secret) not]`))
+
+	// hidden members, wrong arguments and values which do not fit
+	_, err = vm.Evaluate(`widget secret`)
+	_, ok := err.(*treeNodes.DoesNotUnderstandError)
+	testutils.ASSERT_TRUE(t, ok)
+	_, err = vm.Evaluate(`widget disable`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	testutils.ASSERT_FALSE(t, widget.disabled)
+	_, err = vm.Evaluate(`widget width: 'wide'`)
+	_, ok = err.(*treeNodes.TypeMismatchError)
+	testutils.ASSERT_TRUE(t, ok)
+	_, err = vm.Evaluate(`widget width: 2.5`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	testutils.ASSERT_EQ(t, widget.Width, 5)
+
+	// a struct value is read only
+	vm.SetGoObject(`point`, testPoint{3, 4})
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`point x + point y`)), 7)
+	_, err = vm.Evaluate(`point x: 1`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = vm.SetGoObject(`nothing`, nil)
+	testutils.ASSERT_TRUE(t, err != nil)
+	value := vm.EvaluateToInterface(`widget`)
+	testutils.ASSERT_TRUE(t, value.(*testWidget) == widget)
+
+	// results of messages to Go objects inside of collections are not cached either
+	proxy, _ := treeNodes.NewSmalltalkGoObject(widget)
+	vm.SetVar(`widgets`, treeNodes.NewSmalltalkArray([]treeNodes.SmalltalkObjectInterface{proxy}))
+	widget.Width = 8
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`widgets first width`)), 8)
+	widget.Width = 9
+	testutils.ASSERT_EQ(t, int(vm.EvaluateToInt64(`widgets first width`)), 9)
+}
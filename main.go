@@ -34,9 +34,13 @@ func main() {
 		input, err := consoleReader.ReadString('\n') // this will prompt the user for input
 		if err == nil {
 			input := strings.Split(input, "\n")[0]
-			result := vm.EvaluateToInterface(input)
+			result, err := vm.EvaluateToInterfaceE(input)
 			fmt.Print(">>> ")
-			fmt.Println(result)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				fmt.Println(result)
+			}
 		}
 	}
 }
@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -15,12 +15,30 @@ type Parser struct {
 	currentToken    scanner.TokenInterface
 	peekToken       scanner.TokenInterface
 	emptyStatements bool
+	comments        []*scanner.Comment
+}
+
+type ParseError struct {
+	Message  string
+	Position int64
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at %d: %s", e.Position, e.Message)
+}
+
+func (p *Parser) parseError(message string) *ParseError {
+	var position int64
+	if p.currentToken != nil {
+		position = p.currentToken.GetStart()
+	}
+	return &ParseError{message, position}
 }
 
 func InitializeParserFor(expressionString string) (treeNodes.ProgramNodeInterface, error) {
 	reader := talkio.NewReader(expressionString)
 	scanner := scanner.New(*reader)
-	parser := &Parser{scanner, nil, nil, false}
+	parser := &Parser{scanner: scanner}
 
 	//initialize struct members
 	err := parser.step()
@@ -31,13 +49,88 @@ func InitializeParserFor(expressionString string) (treeNodes.ProgramNodeInterfac
 	if err != nil {
 		return nil, err
 	}
+	if !parser.atEnd() {
+		return nil, parser.parseError("unknown input at the end of expression")
+	}
 	if len(node.GetStatements()) == 1 && len(node.GetTemporaries()) == 0 {
+		node.GetStatements()[0].AddComments(node.GetComments())
 		return node.GetStatements()[0], nil
 	} else {
 		return node, nil
 	}
 }
 
+// ParseMethod parses the source of a method: a message pattern like at: index put: value followed by
+// temporaries and statements
+func ParseMethod(methodString string) (*treeNodes.MethodNode, error) {
+	reader := talkio.NewReader(methodString)
+	scanner := scanner.New(*reader)
+	parser := &Parser{scanner: scanner}
+
+	err := parser.step()
+	if err != nil {
+		return nil, err
+	}
+	node, err := parser.parseMessagePattern()
+	if err != nil {
+		return nil, err
+	}
+	body, err := parser.parseStatements(false)
+	if err != nil {
+		return nil, err
+	}
+	if !parser.atEnd() {
+		return nil, parser.parseError("unknown input at the end of method")
+	}
+	node.SetBody(body)
+	node.SetSource(methodString)
+	return node, nil
+}
+
+func (p *Parser) parseMessagePattern() (*treeNodes.MethodNode, error) {
+	node := treeNodes.NewMethodNode()
+	if p.atEnd() {
+		return nil, p.parseError("message pattern expected")
+	}
+	if p.currentToken.IsIdentifier() {
+		node.SetSelector(p.currentToken.(scanner.ValueTokenInterface).ValueOfToken())
+		return node, p.step()
+	}
+	var selector string
+	var args []*treeNodes.VariableNode
+	if p.currentToken.IsBinary() {
+		selector = p.currentToken.(scanner.ValueTokenInterface).ValueOfToken()
+		err := p.step()
+		if err != nil {
+			return nil, err
+		}
+		arg, err := p.parseVariableNode()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	} else {
+		for p.currentToken.IsKeyword() {
+			selector = selector + p.currentToken.(scanner.ValueTokenInterface).ValueOfToken()
+			err := p.step()
+			if err != nil {
+				return nil, err
+			}
+			arg, err := p.parseVariableNode()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+	if selector == "" {
+		return nil, p.parseError("message pattern expected")
+	}
+	node.SetSelector(selector)
+	node.SetArguments(args)
+	return node, nil
+}
+
 func (p *Parser) parseExpression() (*treeNodes.SequenceNode, error) {
 	return p.parseStatements(false)
 }
@@ -55,8 +148,11 @@ func (p *Parser) parseStatements(tagBool bool) (*treeNodes.SequenceNode, error)
 				return nil, err
 			}
 			args, err = p.parseArgs()
-			if err != nil || !(p.currentToken.IsBinary() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "|") {
-				return nil, errors.New("Parse error in parseStatements function.")
+			if err != nil {
+				return nil, err
+			}
+			if !(p.currentToken.IsBinary() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "|") {
+				return nil, p.parseError("Parse error in parseStatements function.")
 			}
 			rightBar = p.currentToken.GetStart()
 			err = p.step()
@@ -97,11 +193,22 @@ func (p *Parser) parseArgs() ([]*treeNodes.VariableNode, error) {
 	return args, nil
 }
 
+// pseudoVariables are bound by the evaluator, so programs can neither assign nor declare them
+var pseudoVariables = map[string]bool{`self`: true, `super`: true, `thisContext`: true, `nil`: true, `true`: true, `false`: true}
+
+func (p *Parser) pseudoVariableError() *ParseError {
+	name := p.currentToken.(scanner.ValueTokenInterface).ValueOfToken()
+	return p.parseError(name + " is a pseudo-variable, it cannot be assigned or declared")
+}
+
 func (p *Parser) parseVariableNode() (*treeNodes.VariableNode, error) {
 	if p.currentToken.IsIdentifier() {
+		if pseudoVariables[p.currentToken.(scanner.ValueTokenInterface).ValueOfToken()] {
+			return nil, p.pseudoVariableError()
+		}
 		return p.parsePrimitiveIdentifier()
 	} else {
-		return nil, errors.New("we expect variable name here btw")
+		return nil, p.parseError("we expect variable name here btw")
 	}
 }
 
@@ -114,11 +221,23 @@ func (p *Parser) parseStatementListInto(tagBool bool, sequenceNode *treeNodes.Se
 	}
 	for !(p.atEnd() || (p.currentToken.IsSpecial() && IncludesInString("])}", p.currentToken.(scanner.ValueTokenInterface).ValueOfToken()))) {
 		if returnFlag {
-			return nil, errors.New("End of statement list encountered")
+			return nil, p.parseError("End of statement list encountered")
 		}
 		if p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == `^` {
-			//TODO: smalltalk return statement ^
-			return nil, errors.New("we should not have smalltalk return statement eg ^ in our script")
+			returnPosition := p.currentToken.GetStart()
+			err := p.step()
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.parseAssignment()
+			if err != nil {
+				return nil, err
+			}
+			returnNode := treeNodes.NewReturnNode()
+			returnNode.SetPosition(returnPosition)
+			returnNode.SetValue(value)
+			statements = append(statements, returnNode)
+			returnFlag = true
 		} else {
 			node, err := p.parseAssignment()
 			if err != nil {
@@ -126,13 +245,16 @@ func (p *Parser) parseStatementListInto(tagBool bool, sequenceNode *treeNodes.Se
 			}
 			statements = append(statements, node)
 		}
+		statement := statements[len(statements)-1]
+		p.addCommentsTo(statement)
 		if p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == `.` {
 			periods = append(periods, p.currentToken.GetStart())
 			err := p.step()
 			if err != nil {
 				return nil, err
 			}
-			//TODO: comments
+			// comments which follow the period belong to the statement before it
+			p.addCommentsTo(statement)
 		} else {
 			returnFlag = true
 		}
@@ -148,11 +270,29 @@ func (p *Parser) parseStatementListInto(tagBool bool, sequenceNode *treeNodes.Se
 	}
 	sequenceNode.SetStatements(statements)
 	sequenceNode.SetPeriods(periods)
+	p.addCommentsTo(sequenceNode)
 	return sequenceNode, nil
 }
 
 func (p *Parser) parseAssignment() (treeNodes.ValueNodeInterface, error) {
-	if !(p.currentToken.IsIdentifier() && p.nextToken().IsAssignment()) {
+	if !p.currentToken.IsIdentifier() {
+		if p.currentToken.TypeOfToken() == scanner.NIL || p.currentToken.TypeOfToken() == scanner.BOOLEAN {
+			// nil, true and false are literals, so they are checked here
+			nextToken, err := p.nextToken()
+			if err != nil {
+				return nil, err
+			}
+			if nextToken.IsAssignment() {
+				return nil, p.pseudoVariableError()
+			}
+		}
+		return p.parseCascadeMessage()
+	}
+	nextToken, err := p.nextToken()
+	if err != nil {
+		return nil, err
+	}
+	if !nextToken.IsAssignment() {
 		return p.parseCascadeMessage()
 	}
 	node, err := p.parseVariableNode()
@@ -196,6 +336,11 @@ func (p *Parser) parseCascadeMessage() (treeNodes.ValueNodeInterface, error) {
 		}
 		var message *treeNodes.MessageNode
 		if p.currentToken.IsIdentifier() {
+			message, err = p.parseUnaryMessageWith(receiver)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.currentToken.IsKeyword() {
 			tmpMsg, err := p.parseKeywordMessageWith(receiver)
 			if err != nil {
 				return nil, err
@@ -206,14 +351,14 @@ func (p *Parser) parseCascadeMessage() (treeNodes.ValueNodeInterface, error) {
 				p.patchNegativeLiteral()
 			}
 			if !p.currentToken.IsBinary() {
-				return nil, errors.New("message expected")
+				return nil, p.parseError("message expected")
 			}
 			temp, err := p.parseBinaryMessageWith(receiver)
 			if err != nil {
 				return nil, err
 			}
 			if temp == receiver {
-				return nil, errors.New("message expected")
+				return nil, p.parseError("message expected")
 			}
 			message = temp
 		}
@@ -231,17 +376,21 @@ func (p *Parser) patchNegativeLiteral() {
 		return
 	}
 	strVal := p.currentToken.(*scanner.NumberLiteralToken).ValueOfToken()
-	value, err := strconv.ParseFloat(strVal, 64)
-	if err != nil {
-		return
-	}
-
-	if value >= 0 {
+	if !strings.HasPrefix(strVal, "-") {
 		return
 	}
 	p.peekToken = p.currentToken
 	p.currentToken = scanner.NewBinarySelectorToken(p.peekToken.GetStart(), `-`)
-	p.peekToken.(*scanner.NumberLiteralToken).SetValue(strconv.FormatFloat(value*-1, 'f', 2, 64))
+	// comments of the literal are already collected, so they move to the selector
+	p.currentToken.SetComments(p.peekToken.GetComments())
+	p.peekToken.SetComments(nil)
+	numberToken := p.peekToken.(*scanner.NumberLiteralToken)
+	numberToken.SetExactValue(strings.TrimPrefix(strVal, "-"))
+	if value, err := strconv.ParseFloat(strVal, 64); err == nil {
+		numberToken.SetValue(strconv.FormatFloat(value*-1, 'f', 2, 64))
+	} else {
+		numberToken.SetValue(numberToken.ExactValue())
+	}
 	if p.peekToken.TypeOfToken() == scanner.NUMBER {
 		//TODO: working with source code for token
 	}
@@ -357,7 +506,9 @@ func (p *Parser) parsePrimitiveObject() (treeNodes.ValueNodeInterface, error) {
 		return p.parsePrimitiveLiteral()
 	}
 	if p.currentToken.IsLiteralArrayToken() {
-		//TODO: ByteArray
+		if p.currentToken.IsForByteArray() {
+			return p.parseLiteralByteArray()
+		}
 		return p.parseLiteralArray()
 	}
 	if p.currentToken.IsSpecial() {
@@ -367,9 +518,12 @@ func (p *Parser) parsePrimitiveObject() (treeNodes.ValueNodeInterface, error) {
 		if p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "(" {
 			return p.parseParenthesizedExpression()
 		}
+		if p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "{" {
+			return p.parseDynamicArray()
+		}
 	}
 	//in case of emergency LUL
-	return nil, errors.New("what is our token?")
+	return nil, p.parseError("what is our token?")
 }
 
 func (p *Parser) parseBlock() (*treeNodes.BlockNode, error) {
@@ -390,7 +544,7 @@ func (p *Parser) parseBlock() (*treeNodes.BlockNode, error) {
 	}
 	node.SetBody(parsedStatements)
 	if !(p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "]") {
-		return nil, errors.New("Close bracket expected smth like ]")
+		return nil, p.parseError("Close bracket expected smth like ]")
 	}
 	node.SetRight(p.currentToken.GetStart())
 	err = p.step()
@@ -426,11 +580,11 @@ func (p *Parser) parseBlockArgsInto(node *treeNodes.BlockNode) (*treeNodes.Block
 					return nil, err
 				}
 			} else {
-				return nil, errors.New("bar inside block node is expected")
+				return nil, p.parseError("bar inside block node is expected")
 			}
 		} else {
 			if !(p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "]") {
-				return nil, errors.New("bar inside block node is expected")
+				return nil, p.parseError("bar inside block node is expected")
 			}
 		}
 	}
@@ -460,8 +614,32 @@ func (p *Parser) parseParenthesizedExpression() (treeNodes.ValueNodeInterface, e
 		}
 		return node, nil
 	} else {
-		return nil, errors.New("close parenthesis expected. something like ) ")
+		return nil, p.parseError("close parenthesis expected. something like ) ")
+	}
+}
+
+// parseDynamicArray parses a brace array like {x. y. x + y}. Its elements are statements separated by periods.
+func (p *Parser) parseDynamicArray() (*treeNodes.DynamicArrayNode, error) {
+	node := treeNodes.NewDynamicArrayNode()
+	node.SetLeft(p.currentToken.GetStart())
+	err := p.step()
+	if err != nil {
+		return nil, err
 	}
+	statements, err := p.parseStatementListInto(false, treeNodes.NewSequenceNode())
+	if err != nil {
+		return nil, err
+	}
+	if !(p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "}") {
+		return nil, p.parseError("close brace expected. something like }")
+	}
+	node.SetStatements(statements.GetStatements())
+	node.SetRight(p.currentToken.GetStart())
+	err = p.step()
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
 }
 
 func (p *Parser) parseLiteralArray() (treeNodes.LiteralNodeInterface, error) {
@@ -479,7 +657,7 @@ func (p *Parser) parseLiteralArray() (treeNodes.LiteralNodeInterface, error) {
 		contents = append(contents, parsedLiteralArray)
 	}
 	if !(p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == ")") {
-		return nil, errors.New("hmm parse error btw. we expect ) here")
+		return nil, p.parseError("hmm parse error btw. we expect ) here")
 	}
 	stop := p.currentToken.(scanner.ValueTokenInterface).GetStop()
 	err = p.step()
@@ -490,27 +668,82 @@ func (p *Parser) parseLiteralArray() (treeNodes.LiteralNodeInterface, error) {
 	return node, nil
 }
 
+// parseLiteralArrayObject parses an element of a literal array. Like in Pharo, ( and [ start nested arrays and byte arrays
+// without #, and identifiers, keywords and binary selectors are symbols, so #(foo at:put: +) has three symbols.
 func (p *Parser) parseLiteralArrayObject() (treeNodes.LiteralNodeInterface, error) {
 	if p.currentToken.IsSpecial() {
 		if p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "(" {
 			return p.parseLiteralArray()
 		}
-		//TODO: ByteArray
-		/*if p.currentToken.ValueOfToken() == "[" {
+		if p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "[" {
 			return p.parseLiteralByteArray()
-		}*/
+		}
 	}
 	if p.currentToken.IsLiteralArrayToken() {
 		if p.currentToken.IsForByteArray() {
-			//TODO: ByteArray
-			return nil, errors.New("Not implemented")
+			return p.parseLiteralByteArray()
 		} else {
 			return p.parseLiteralArray()
 		}
 	}
-	//TODO: Optimized token
-	//TODO: patchLiteralArrayToken
-	return p.parsePrimitiveLiteral()
+	if p.currentToken.IsIdentifier() || p.currentToken.IsKeyword() || p.currentToken.IsBinary() || p.isMultiKeyword() {
+		return p.parseSymbolInLiteralArray()
+	}
+	if p.currentToken.IsLiteralToken() {
+		return p.parsePrimitiveLiteral()
+	}
+	return nil, p.parseError("literal expected in literal array")
+}
+
+func (p *Parser) isMultiKeyword() bool {
+	literal, ok := p.currentToken.(scanner.LiteralTokenInterface)
+	return ok && p.currentToken.IsLiteralToken() && literal.IsMultiKeyword()
+}
+
+// parseSymbolInLiteralArray parses a name without # in a literal array as a symbol
+func (p *Parser) parseSymbolInLiteralArray() (treeNodes.LiteralNodeInterface, error) {
+	token := p.currentToken.(scanner.ValueTokenInterface)
+	name := strings.TrimPrefix(token.ValueOfToken(), "#")
+	symbol := scanner.NewLiteralToken(token.GetStart(), token.GetStop(), name, scanner.SYMBOL)
+	err := p.step()
+	if err != nil {
+		return nil, err
+	}
+	return treeNodes.NewLiteralNode().LiteralToken(symbol), nil
+}
+
+// parseLiteralByteArray parses a byte array like #[1 2 255]. It has only integers from 0 to 255.
+func (p *Parser) parseLiteralByteArray() (treeNodes.LiteralNodeInterface, error) {
+	var contents []treeNodes.LiteralNodeInterface
+	start := p.currentToken.GetStart()
+	err := p.step()
+	if err != nil {
+		return nil, err
+	}
+	for !(p.atEnd() || (p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "]")) {
+		if p.currentToken.TypeOfToken() != scanner.NUMBER || !isByte(p.currentToken.(scanner.ValueTokenInterface).ValueOfToken()) {
+			return nil, p.parseError("byte array can contain only integers from 0 to 255")
+		}
+		byteLiteral, err := p.parsePrimitiveLiteral()
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, byteLiteral)
+	}
+	if !(p.currentToken.IsSpecial() && p.currentToken.(scanner.ValueTokenInterface).ValueOfToken() == "]") {
+		return nil, p.parseError("] expected at the end of byte array")
+	}
+	stop := p.currentToken.(scanner.ValueTokenInterface).GetStop()
+	err = p.step()
+	if err != nil {
+		return nil, err
+	}
+	return treeNodes.CreateLiteralByteArrayNode(start, stop, contents), nil
+}
+
+func isByte(number string) bool {
+	value, err := strconv.Atoi(number)
+	return err == nil && value >= 0 && value <= 255
 }
 
 func (p *Parser) parsePrimitiveIdentifier() (*treeNodes.VariableNode, error) {
@@ -542,19 +775,38 @@ func (p *Parser) step() error {
 	} else {
 		currentToken, err := p.scanner.Next()
 		if err != nil {
-			return err
+			return scanErrorToParseError(err)
 		}
 		p.currentToken = currentToken
 	}
+	p.comments = append(p.comments, p.currentToken.GetComments()...)
 	return nil
 }
 
-func (p *Parser) nextToken() scanner.TokenInterface {
+// addCommentsTo gives the comments collected since the previous node to node
+func (p *Parser) addCommentsTo(node treeNodes.ProgramNodeInterface) {
+	if len(p.comments) > 0 {
+		node.AddComments(p.comments)
+		p.comments = nil
+	}
+}
+
+func (p *Parser) nextToken() (scanner.TokenInterface, error) {
 	if p.peekToken == nil {
-		peekToken, _ := p.scanner.Next()
+		peekToken, err := p.scanner.Next()
+		if err != nil {
+			return nil, scanErrorToParseError(err)
+		}
 		p.peekToken = peekToken
 	}
-	return p.peekToken
+	return p.peekToken, nil
+}
+
+func scanErrorToParseError(err error) error {
+	if scanError, ok := err.(*scanner.ScanError); ok {
+		return &ParseError{scanError.Message, scanError.Position}
+	}
+	return err
 }
 
 func (p *Parser) atEnd() bool {
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/SealNTibbers/GotalkInterpreter/scanner"
@@ -58,6 +59,22 @@ func TestAssignmentLiteralArrayParser(t *testing.T) {
 	testutils.ASSERT_STREQ(t, assignmentNode.(*treeNodes.AssignmentNode).GetValue().(*treeNodes.LiteralArrayNode).GetValue(), "1 2")
 }
 
+func TestNestedLiteralArrayParser(t *testing.T) {
+	inputString := `#(1 $a foo at:put: + (1 2) #[1 2 3])`
+	arrayNode, err := InitializeParserFor(inputString)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_TRUE(t, arrayNode.IsLiteralArray())
+	testutils.ASSERT_STREQ(t, arrayNode.(*treeNodes.LiteralArrayNode).GetValue(), "1 a foo at:put: + 1 2 1 2 3")
+
+	byteArrayNode, _ := InitializeParserFor(`#[0 255]`)
+	testutils.ASSERT_TRUE(t, byteArrayNode.(*treeNodes.LiteralArrayNode).IsByteArray())
+
+	_, err = InitializeParserFor(`#[1 256]`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = InitializeParserFor(`#[1 $a]`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
 func TestBinaryMessageParser(t *testing.T) {
 	inputString := `a + b`
 	messageNode, _ := InitializeParserFor(inputString)
@@ -185,3 +202,134 @@ func TestIdentifierParser(t *testing.T) {
 	variableNode, _ := InitializeParserFor(inputString)
 	testutils.ASSERT_STREQ(t, variableNode.(*treeNodes.VariableNode).GetName(), "radio_altitude")
 }
+
+func TestCascadeParser(t *testing.T) {
+	inputString := `a foo: b; bar; + c`
+	cascadeNode, _ := InitializeParserFor(inputString)
+	testutils.ASSERT_TRUE(t, len(cascadeNode.(*treeNodes.CascadeNode).GetMessages()) == 3)
+	testutils.ASSERT_STREQ(t, cascadeNode.(*treeNodes.CascadeNode).GetReceiver().(*treeNodes.VariableNode).GetName(), "a")
+	testutils.ASSERT_STREQ(t, cascadeNode.(*treeNodes.CascadeNode).GetMessages()[0].GetSelector(), "foo:")
+	testutils.ASSERT_STREQ(t, cascadeNode.(*treeNodes.CascadeNode).GetMessages()[1].GetSelector(), "bar")
+	testutils.ASSERT_STREQ(t, cascadeNode.(*treeNodes.CascadeNode).GetMessages()[2].GetSelector(), "+")
+	variables := cascadeNode.GetVariables()
+	testutils.ASSERT_TRUE(t, len(variables) == 3)
+	testutils.ASSERT_STREQ(t, variables[0], "a")
+	testutils.ASSERT_STREQ(t, variables[1], "b")
+	testutils.ASSERT_STREQ(t, variables[2], "c")
+}
+
+func TestDynamicArrayParser(t *testing.T) {
+	inputString := `{x. y. x + z}`
+	arrayNode, err := InitializeParserFor(inputString)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, arrayNode.TypeOfNode(), "DynamicArrayNode")
+	testutils.ASSERT_TRUE(t, len(arrayNode.(*treeNodes.DynamicArrayNode).GetStatements()) == 3)
+	variables := arrayNode.GetVariables()
+	testutils.ASSERT_TRUE(t, len(variables) == 4)
+	testutils.ASSERT_STREQ(t, variables[0], "x")
+	testutils.ASSERT_STREQ(t, variables[1], "x")
+	testutils.ASSERT_STREQ(t, variables[2], "y")
+	testutils.ASSERT_STREQ(t, variables[3], "z")
+
+	emptyNode, err := InitializeParserFor(`{}`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_TRUE(t, len(emptyNode.(*treeNodes.DynamicArrayNode).GetStatements()) == 0)
+
+	_, err = InitializeParserFor(`{x. y`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestReturnParser(t *testing.T) {
+	inputString := `a < 0 ifTrue: [^0]. ^a sqrt`
+	sequenceNode, err := InitializeParserFor(inputString)
+	testutils.ASSERT_TRUE(t, err == nil)
+	statements := sequenceNode.(*treeNodes.SequenceNode).GetStatements()
+	testutils.ASSERT_TRUE(t, len(statements) == 2)
+	innerReturn := statements[0].(*treeNodes.MessageNode).GetArguments()[0].(*treeNodes.BlockNode).GetBody().GetStatements()[0]
+	testutils.ASSERT_TRUE(t, innerReturn.IsReturn())
+	testutils.ASSERT_STREQ(t, innerReturn.(*treeNodes.ReturnNode).GetValue().(*treeNodes.LiteralValueNode).GetValue(), "0")
+	testutils.ASSERT_TRUE(t, statements[1].IsReturn())
+	testutils.ASSERT_STREQ(t, statements[1].(*treeNodes.ReturnNode).GetValue().(*treeNodes.MessageNode).GetSelector(), "sqrt")
+	testutils.ASSERT_TRUE(t, statements[1].(*treeNodes.ReturnNode).GetPosition() == 21)
+
+	_, err = InitializeParserFor(`^a. b`)
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestCommentsParser(t *testing.T) {
+	inputString := `"clamp" x := speed max: 0. "km/h"
+	y := x * 2 "double"`
+	sequenceNode, err := InitializeParserFor(inputString)
+	testutils.ASSERT_TRUE(t, err == nil)
+	statements := sequenceNode.(*treeNodes.SequenceNode).GetStatements()
+	testutils.ASSERT_EQ(t, len(statements[0].GetComments()), 2)
+	testutils.ASSERT_STREQ(t, statements[0].GetComments()[0].Value, "clamp")
+	testutils.ASSERT_STREQ(t, statements[0].GetComments()[1].Value, "km/h")
+	testutils.ASSERT_STREQ(t, statements[1].GetComments()[0].Value, "double")
+
+	node, err := InitializeParserFor(`3 + -4 "negative" "literal"`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, len(node.GetComments()), 2)
+
+	node, err = InitializeParserFor(`[:a | a "argument"] value: 1`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	block := node.(*treeNodes.MessageNode).GetReceiver().(*treeNodes.BlockNode)
+	testutils.ASSERT_STREQ(t, block.GetBody().GetStatements()[0].GetComments()[0].Value, "argument")
+
+	_, err = InitializeParserFor(`x + 1 "unterminated`)
+	testutils.ASSERT_TRUE(t, err != nil)
+	testutils.ASSERT_EQ(t, int(err.(*ParseError).Position), 7)
+}
+
+func TestMethodParser(t *testing.T) {
+	method, err := ParseMethod("span\n\t^max - min")
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, method.GetSelector(), "span")
+	testutils.ASSERT_EQ(t, len(method.GetArguments()), 0)
+	testutils.ASSERT_TRUE(t, method.GetBody().GetStatements()[0].IsReturn())
+
+	method, err = ParseMethod("at: index put: value\n\t| old |\n\told := index.\n\t^value")
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, method.GetSelector(), "at:put:")
+	testutils.ASSERT_STREQ(t, method.GetArguments()[1].GetName(), "value")
+	testutils.ASSERT_EQ(t, len(method.GetBody().GetTemporaries()), 1)
+	testutils.ASSERT_EQ(t, len(method.GetBody().GetStatements()), 2)
+
+	method, err = ParseMethod("+ other ^self")
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, method.GetSelector(), "+")
+	testutils.ASSERT_STREQ(t, method.GetArguments()[0].GetName(), "other")
+
+	_, err = ParseMethod("at: 3")
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = ParseMethod("")
+	testutils.ASSERT_TRUE(t, err != nil)
+	_, err = ParseMethod("span ^max -")
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestPseudoVariableParser(t *testing.T) {
+	for _, input := range []string{`self := 3`, `x := super := 3`, `thisContext := nil`, `nil := 3`, `true := false`, `| self | 3`, `[:super | 3]`} {
+		_, err := InitializeParserFor(input)
+		parseError, ok := err.(*ParseError)
+		testutils.ASSERT_TRUE(t, ok)
+		testutils.ASSERT_TRUE(t, strings.Contains(parseError.Message, "pseudo-variable"))
+	}
+	_, err := InitializeParserFor(`x := super := 3`)
+	testutils.ASSERT_EQ(t, int(err.(*ParseError).Position), 6)
+	_, err = ParseMethod("at: self put: value ^value")
+	testutils.ASSERT_TRUE(t, err != nil)
+
+	node, err := InitializeParserFor(`super foo; bar`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_TRUE(t, node.(*treeNodes.CascadeNode).GetMessages()[1].IsSuperSend())
+	node, err = InitializeParserFor(`self foo`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_FALSE(t, node.(*treeNodes.MessageNode).IsSuperSend())
+}
+
+func TestUnusedBlockArgumentVariables(t *testing.T) {
+	programNode, err := InitializeParserFor(`| s | s := 0. #(1 2) do: [:each | s := s + 1]. [:x :y | y] value: a value: b`)
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, strings.Join(programNode.GetVariables(), " "), "a b")
+}
@@ -1,8 +1,9 @@
 package scanner
 
 import (
-	"errors"
+	"fmt"
 	"math"
+	"math/big"
 	"strconv"
 	"strings"
 	"unicode"
@@ -18,16 +19,36 @@ const (
 	SPEC      = "#special"
 	SEPARATOR = "#separator"
 
-	BOOLEAN = "boolean"
-	NIL     = "nil"
-	STRING  = "string"
-	NUMBER  = "number"
-	IDENT   = "identifier"
-	SYMBOL  = "symbol"
-	ARRAY   = "array"
-	KEYWORD = "keyword"
+	BOOLEAN   = "boolean"
+	NIL       = "nil"
+	STRING    = "string"
+	NUMBER    = "number"
+	IDENT     = "identifier"
+	SYMBOL    = "symbol"
+	ARRAY     = "array"
+	KEYWORD   = "keyword"
+	CHARACTER = "character"
 )
 
+// maxExponent is the biggest exponent and scale of number literals. Bigger ones would make numbers with millions of digits.
+const maxExponent = 10000
+
+type ScanError struct {
+	Message  string
+	Position int64
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("scan error at %d: %s", e.Position, e.Message)
+}
+
+// Comment is a "double-quoted comment" from the source code. Its value has no quotes.
+type Comment struct {
+	Value string
+	Start int64
+	Stop  int64
+}
+
 func New(input talkio.StringReader) *Scanner {
 
 	scanner := &Scanner{}
@@ -52,6 +73,8 @@ type Scanner struct {
 	currentCharacter    rune
 	tokenStart          int64
 	token               TokenInterface
+	comments            []*Comment
+	err                 error
 }
 
 func (s *Scanner) on(input talkio.StringReader) {
@@ -73,16 +96,38 @@ func (s *Scanner) step() rune {
 	return s.currentCharacter
 }
 
+// stripSeparators skips separators and comments. Comments are kept for the next token.
 func (s *Scanner) stripSeparators() {
 	for {
 		if s.characterType == SEPARATOR {
 			s.step()
+		} else if s.currentCharacter == '"' && s.characterType != EOF {
+			if !s.scanComment() {
+				break
+			}
 		} else {
 			break
 		}
 	}
 }
 
+func (s *Scanner) scanComment() bool {
+	start := s.stream.GetPosition()
+	s.buffer.Reset()
+	s.step()
+	for s.currentCharacter != '"' {
+		if s.characterType == EOF {
+			s.err = &ScanError{"UnmatchedCommentQuote", start}
+			return false
+		}
+		s.buffer.WriteRune(s.currentCharacter)
+		s.step()
+	}
+	s.comments = append(s.comments, &Comment{s.buffer.String(), start, s.stream.GetPosition()})
+	s.step()
+	return true
+}
+
 func (s *Scanner) getClassificationTable() []string {
 	if s.classificationTable == nil {
 		s.initializeClassificationTable()
@@ -119,7 +164,7 @@ func (s *Scanner) initializeClassificationTable() []string {
 	s.classificationTable[215] = BIN
 	s.classificationTable[247] = BIN
 
-	s.initializeRuneTypes(`().:;[]^`, SPEC)
+	s.initializeRuneTypes(`().:;[]^{}`, SPEC)
 
 	return s.classificationTable
 }
@@ -149,10 +194,13 @@ func (s *Scanner) classify(character rune) string {
 }
 
 func (s *Scanner) Next() (TokenInterface, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
 	s.buffer.Reset()
 	s.tokenStart = s.stream.GetPosition()
 	if s.characterType == EOF {
-		s.token = &EOFToken{&Token{s.tokenStart + 1}}
+		s.token = &EOFToken{&Token{sourcePointer: s.tokenStart + 1}}
 	} else {
 		sT, err := s.scanToken()
 		if err != nil {
@@ -160,10 +208,18 @@ func (s *Scanner) Next() (TokenInterface, error) {
 		}
 		s.token = sT
 	}
+	if s.token != nil {
+		s.token.SetComments(s.comments)
+	}
+	s.comments = nil
 	s.stripSeparators()
 	return s.token, nil
 }
 
+func (s *Scanner) scanError(message string) *ScanError {
+	return &ScanError{message, s.tokenStart}
+}
+
 func (s *Scanner) previousStepPosition() int64 {
 	if s.characterType == EOF {
 		return s.stream.GetPosition()
@@ -172,12 +228,21 @@ func (s *Scanner) previousStepPosition() int64 {
 	}
 }
 
+// isNegativeNumber answers whether the current - starts a number. A - at the end of the source is a binary selector.
+func (s *Scanner) isNegativeNumber() bool {
+	if s.currentCharacter != '-' {
+		return false
+	}
+	next, err := s.stream.PeekRuneError()
+	return err == nil && s.classify(next) == DIGIT
+}
+
 func (s *Scanner) scanToken() (TokenInterface, error) {
 	if s.characterType == ALPHABET {
 		return s.scanIdentifierOrKeyword(), nil
 	}
 
-	if s.characterType == DIGIT || (s.currentCharacter == '-' && s.classify(s.stream.PeekRune()) == DIGIT) {
+	if s.characterType == DIGIT || s.isNegativeNumber() {
 		return s.scanNumber()
 	}
 
@@ -197,6 +262,10 @@ func (s *Scanner) scanToken() (TokenInterface, error) {
 		return s.scanLiteral()
 	}
 
+	if s.currentCharacter == '$' {
+		return s.scanCharacter()
+	}
+
 	return &Token{}, nil
 }
 
@@ -216,7 +285,7 @@ func (s *Scanner) scanIdentifierOrKeyword() TokenInterface {
 	if name == "nil" {
 		return NewLiteralToken(s.tokenStart, s.previousStepPosition(), "nil", NIL)
 	}
-	return &IdentifierToken{&ValueToken{&Token{s.tokenStart}, name, IDENT}}
+	return &IdentifierToken{&ValueToken{&Token{sourcePointer: s.tokenStart}, name, IDENT}}
 }
 
 func (s *Scanner) scanKeyword() TokenInterface {
@@ -245,7 +314,7 @@ func (s *Scanner) scanKeyword() TokenInterface {
 	s.step()
 	name := s.buffer.String()
 	if (strings.Count(name, ":")) == 1 {
-		return &KeywordToken{&ValueToken{&Token{s.tokenStart}, name, KEYWORD}}
+		return &KeywordToken{&ValueToken{&Token{sourcePointer: s.tokenStart}, name, KEYWORD}}
 	} else {
 		return &MultiKeywordLiteralToken{NewLiteralToken(s.tokenStart, s.tokenStart+(int64)(len(name)), "#"+name, KEYWORD)}
 	}
@@ -283,14 +352,14 @@ func (s *Scanner) scanNumber() (*NumberLiteralToken, error) {
 	}
 	_, err = s.stream.ReadRunes(stop - start + 1)
 	if err != nil {
-		return nil, errors.New("can't read an amount of runes to scan number")
+		return nil, s.scanError("can't read an amount of runes to scan number")
 	}
 	err = s.stream.SetPosition(currentPosition)
 	if err != nil {
 		return nil, err
 	}
 
-	return &NumberLiteralToken{NewLiteralToken(start, stop, string(number), NUMBER)}, nil
+	return &NumberLiteralToken{LiteralToken: NewLiteralToken(start, stop, string(number), NUMBER)}, nil
 }
 
 func (s *Scanner) scanNumberVisualWorks() (string, error) {
@@ -306,23 +375,71 @@ func (s *Scanner) scanNumberVisualWorks() (string, error) {
 	return number, nil
 }
 
+// readSmalltalkSyntaxFromStream reads a number like 42, -3.14, 1e-3, 16rFF, -2r1010, 2r1.1e4, 1.5d or 3.14s2.
+// It answers the number in decimal, so the evaluator does not need to know the radix.
 func (s *Scanner) readSmalltalkSyntaxFromStream() (string, error) {
 	if s.stream.AtEnd() || unicode.IsLetter(s.stream.PeekRune()) {
 		return "0", nil
 	}
 	neg := s.stream.PeekRuneFor('-')
-	value, err := s.readIntegerWithRadix(10)
+	digits, err := s.readDigits(10)
 	if err != nil {
 		return "", err
 	}
-	floatValue, err := s.readSmalltalkFloat(value)
+	radix := 10
+	if s.stream.PeekRuneFor('r') {
+		radix, err = strconv.Atoi(digits)
+		if err != nil || radix < 2 || radix > 36 {
+			return "", s.scanError("radix must be from 2 to 36")
+		}
+		if s.stream.PeekRuneFor('-') {
+			neg = !neg
+		}
+		digits, err = s.readDigits(radix)
+		if err != nil {
+			return "", err
+		}
+		if digits == "" {
+			next, err := s.stream.PeekRuneError()
+			if err == nil && 'a' <= next && next <= 'z' && CharToNum(unicode.ToUpper(next)) < radix {
+				return "", s.scanError("digits after 9 must be uppercase letters like in 16rFF")
+			}
+			return "", s.scanError("digits expected after radix " + strconv.Itoa(radix))
+		}
+	}
+	if digits == "" {
+		digits = "0"
+	}
+	number, err := s.readSmalltalkFloat(digits, radix)
 	if err != nil {
 		return "", err
 	}
 	if neg {
-		floatValue *= -1
+		number = "-" + number
 	}
-	return strconv.FormatFloat(floatValue, 'f', -1, 64), nil
+	return number, nil
+}
+
+// readDigits reads the digits of an integer without converting them, so integers of any size are read exactly.
+// Digits after 9 are uppercase letters like in 16rFF.
+func (s *Scanner) readDigits(radix int) (string, error) {
+	var digits strings.Builder
+	for !s.stream.AtEnd() {
+		character, _, err := s.stream.ReadRune()
+		if err != nil {
+			return "", s.scanError("readDigits doesn't work as expected")
+		}
+		digit := CharToNum(character)
+		if digit < 0 || digit >= radix {
+			err = s.stream.Skip(-1)
+			if err != nil {
+				return "", err
+			}
+			break
+		}
+		digits.WriteRune(character)
+	}
+	return digits.String(), nil
 }
 
 func (s *Scanner) readIntegerWithRadix(radix int) (int, error) {
@@ -334,7 +451,7 @@ func (s *Scanner) readIntegerWithRadix(radix int) (int, error) {
 
 		character, _, err := s.stream.ReadRune()
 		if err != nil {
-			return 0, errors.New("readIntegerWithRadix doesn't work as expected. FeelsBadMan")
+			return 0, s.scanError("readIntegerWithRadix doesn't work as expected. FeelsBadMan")
 		}
 		digit := CharToNum(character)
 		if digit < 0 || digit >= radix {
@@ -343,6 +460,9 @@ func (s *Scanner) readIntegerWithRadix(radix int) (int, error) {
 				return 0, err
 			}
 			return value, nil
+		} else if value > (math.MaxInt32-digit)/radix {
+			// the caller rejects such a big value, so it does not need to be exact
+			value = math.MaxInt32
 		} else {
 			value = value*radix + digit
 		}
@@ -350,93 +470,146 @@ func (s *Scanner) readIntegerWithRadix(radix int) (int, error) {
 	return value, nil
 }
 
-func (s *Scanner) readSmalltalkFloat(integerPart int) (float64, error) {
-	var num, den float64
-	var atEnd bool
-	var possibleCoercionClass rune
-	var exp int
-	precision := 0
-	num = 0.0
-	den = 1.0
-	exp = 0
-
+// readSmalltalkFloat reads the fraction part, the scale and the exponent of the number. It answers digits of an integer
+// if there is no fraction part, no d or q suffix and no negative exponent, and a float with a decimal point otherwise.
+// Exponent is a power of the radix, so 2r1e4 is 16. Unlike in Pharo, which reads a fraction, 1e-3 is a float like in VisualWorks.
+func (s *Scanner) readSmalltalkFloat(integerPart string, radix int) (string, error) {
+	fractionPart := ""
 	if s.stream.PeekRuneFor('.') {
-		if !(s.stream.AtEnd()) && unicode.IsDigit(s.stream.PeekRune()) {
-			for {
-				atEnd = s.stream.AtEnd()
-				if atEnd {
-					break
-				}
-				digit, _, err := s.stream.ReadRune()
-				if err != nil {
-					return 0.0, err
-				}
-				if !(unicode.IsDigit(digit)) {
-					break
-				} else {
-					digitValue := CharToNum(digit)
-					num = num*10.0 + float64(digitValue)
-					precision += 1
-				}
-			}
-			den = math.Pow10(precision)
-			if !atEnd {
-				err := s.stream.Skip(-1)
-				if err != nil {
-					return 0, err
-				}
+		digit, err := s.stream.PeekRuneError()
+		if err == nil && CharToNum(digit) >= 0 && CharToNum(digit) < radix {
+			fractionPart, err = s.readDigits(radix)
+			if err != nil {
+				return "", err
 			}
 		} else {
 			//looks like it's just integer
 			err := s.stream.Skip(-1)
 			if err != nil {
-				return 0, err
+				return "", err
 			}
 		}
 	}
 
-	eChar, err := s.stream.PeekRuneError()
-	if err == nil && (eChar == 'e' || eChar == 'd') {
-		if eChar != 0 {
-			possibleCoercionClass, _, _ = s.stream.ReadRune()
+	if s.stream.PeekRuneFor('s') {
+		next, err := s.stream.PeekRuneError()
+		if err != nil || !unicode.IsLetter(next) {
+			return s.readScaledDecimal(integerPart, fractionPart, radix)
+		}
+		// it is a unary message like 2sqrt, not a scale
+		err = s.stream.Skip(-1)
+		if err != nil {
+			return "", err
 		}
+	}
 
-		if possibleCoercionClass != 0 {
-			endOfNumber := s.stream.GetPosition()
-			neg := false
-			if s.stream.PeekRuneFor('-') {
-				neg = true
-			}
-			digit, err := s.stream.PeekRuneError()
-			if err == nil && (digit != 0) && unicode.IsDigit(digit) {
-				exp, err = s.readIntegerWithRadix(10)
-				if err != nil {
-					return 0, err
-				}
-				if neg {
-					exp = -1 * exp
-				}
-			} else {
-				err = s.stream.SetPosition(endOfNumber)
-				if err != nil {
-					return 0, err
-				}
-			}
+	isFloat := fractionPart != ""
+	exp, hasExponent, coercion, err := s.readExponent()
+	if err != nil {
+		return "", err
+	}
+	if coercion == 'd' || coercion == 'q' {
+		isFloat = true
+	}
+	if hasExponent && exp < 0 {
+		isFloat = true
+	}
+
+	value := numberValue(integerPart, fractionPart, radix)
+	if exp > 0 {
+		value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(exp)), nil)))
+	} else if exp < 0 {
+		value.Quo(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(-exp)), nil)))
+	}
+	if !isFloat {
+		return value.Num().String(), nil
+	}
+	floatValue, _ := value.Float64()
+	number := strconv.FormatFloat(floatValue, 'f', -1, 64)
+	if !math.IsInf(floatValue, 0) && !strings.Contains(number, ".") {
+		number += ".0"
+	}
+	return number, nil
+}
+
+// readExponent reads e, d or q and the exponent after it. The letter without an exponent is a float suffix
+// for d and q like in 1.02d, and the beginning of a message for e.
+func (s *Scanner) readExponent() (int, bool, rune, error) {
+	letter, err := s.stream.PeekRuneError()
+	if err != nil || (letter != 'e' && letter != 'd' && letter != 'q') {
+		return 0, false, 0, nil
+	}
+	_, _, err = s.stream.ReadRune()
+	if err != nil {
+		return 0, false, 0, err
+	}
+	afterLetter := s.stream.GetPosition()
+	neg := s.stream.PeekRuneFor('-')
+	digit, err := s.stream.PeekRuneError()
+	if err == nil && unicode.IsDigit(digit) {
+		// positions of tokens count from 1
+		position := s.stream.GetPosition() + 1
+		exp, err := s.readIntegerWithRadix(10)
+		if err != nil {
+			return 0, false, 0, err
+		}
+		if exp > maxExponent {
+			return 0, false, 0, &ScanError{"exponent must not be greater than " + strconv.Itoa(maxExponent), position}
+		}
+		if neg {
+			exp = -exp
 		}
+		return exp, true, letter, nil
 	}
+	err = s.stream.SetPosition(afterLetter)
+	if err != nil {
+		return 0, false, 0, err
+	}
+	next, err := s.stream.PeekRuneError()
+	if letter == 'e' || (err == nil && unicode.IsLetter(next)) {
+		return 0, false, 0, s.stream.Skip(-1)
+	}
+	return 0, false, letter, nil
+}
 
-	value := float64(integerPart) + (num / den)
-	if exp == 0 {
-		return value, nil
-	} else {
-		return value * math.Pow(10, float64(exp)), nil
+// numberValue answers the exact value of the integer and the fraction digits in radix
+func numberValue(integerPart string, fractionPart string, radix int) *big.Rat {
+	mantissa, _ := new(big.Int).SetString(integerPart+fractionPart, radix)
+	denominator := new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(len(fractionPart))), nil)
+	return new(big.Rat).SetFrac(mantissa, denominator)
+}
+
+// readScaledDecimal reads the scale of a ScaledDecimal like 3.14s2. Without digits after s the scale is the number of fraction digits.
+func (s *Scanner) readScaledDecimal(integerPart string, fractionPart string, radix int) (string, error) {
+	scale := len(fractionPart)
+	digit, err := s.stream.PeekRuneError()
+	if err == nil && unicode.IsDigit(digit) {
+		position := s.stream.GetPosition() + 1
+		scale, err = s.readIntegerWithRadix(10)
+		if err != nil {
+			return "", err
+		}
+		if scale > maxExponent {
+			return "", &ScanError{"scale must not be greater than " + strconv.Itoa(maxExponent), position}
+		}
+	}
+	if radix != 10 {
+		return numberValue(integerPart, fractionPart, radix).RatString() + "s" + strconv.Itoa(scale), nil
+	}
+	if fractionPart == "" {
+		return integerPart + "s" + strconv.Itoa(scale), nil
 	}
+	return integerPart + "." + fractionPart + "s" + strconv.Itoa(scale), nil
 }
 
+// CharToNum answers the value of a digit. Letters are digits from 10 to 35, so they have to be uppercase like in 16rFF.
 func CharToNum(r rune) int {
 	if '0' <= r && r <= '9' {
 		return int(r) - '0'
 	}
+	if 'A' <= r && r <= 'Z' {
+		return int(r) - 'A' + 10
+	}
 	return -1
 }
 
@@ -446,14 +619,14 @@ func (s *Scanner) scanSpecialCharacter() TokenInterface {
 		s.step()
 		if s.currentCharacter == '=' {
 			s.step()
-			return &AssignmentToken{&Token{start}}
+			return &AssignmentToken{&Token{sourcePointer: start}}
 		} else {
-			return &SpecialCharacterToken{&ValueToken{&Token{start}, string(':'), SPEC}}
+			return &SpecialCharacterToken{&ValueToken{&Token{sourcePointer: start}, string(':'), SPEC}}
 		}
 	}
 	character := s.currentCharacter
 	s.step()
-	return &SpecialCharacterToken{&ValueToken{&Token{start}, string(character), SPEC}}
+	return &SpecialCharacterToken{&ValueToken{&Token{sourcePointer: start}, string(character), SPEC}}
 }
 
 func (s *Scanner) scanBinaryInSelector() *BinarySelectorToken {
@@ -464,7 +637,7 @@ func (s *Scanner) scanBinaryInSelector() *BinarySelectorToken {
 		s.step()
 	}
 	val := s.buffer.String()
-	binarySelector := &BinarySelectorToken{&ValueToken{&Token{s.tokenStart}, val, BIN}}
+	binarySelector := &BinarySelectorToken{&ValueToken{&Token{sourcePointer: s.tokenStart}, val, BIN}}
 	return binarySelector
 }
 
@@ -476,57 +649,98 @@ func (s *Scanner) scanBinaryInLiteral() *LiteralToken {
 		s.step()
 	}
 	val := s.buffer.String()
-	binarySelector := &LiteralToken{&ValueToken{&Token{s.tokenStart}, val, STRING}, s.previousStepPosition()}
+	binarySelector := &LiteralToken{&ValueToken{&Token{sourcePointer: s.tokenStart}, val, SYMBOL}, s.previousStepPosition()}
 	return binarySelector
 }
 
+// scanSymbol scans unary and keyword symbols like #foo or #at:put:. A keyword symbol ends after its last colon.
+func (s *Scanner) scanSymbol() *LiteralToken {
+	hasColon := false
+	var outputPosition, inputPosition int64
+	for s.characterType == ALPHABET {
+		s.scanName()
+		if s.currentCharacter == ':' {
+			s.buffer.WriteRune(s.currentCharacter)
+			hasColon = true
+			outputPosition = s.buffer.GetPosition()
+			inputPosition = s.stream.GetPosition()
+			s.step()
+		}
+	}
+	if hasColon && !strings.HasSuffix(s.buffer.String(), ":") {
+		_ = s.buffer.SetPosition(outputPosition)
+		_ = s.stream.SetPosition(inputPosition)
+		s.step()
+	}
+	return NewLiteralToken(s.tokenStart, s.previousStepPosition(), s.buffer.String(), SYMBOL)
+}
+
 func (s *Scanner) scanLiteralString() (*LiteralToken, error) {
 	s.step()
 
 	for !(s.currentCharacter == '\'' && s.step() != '\'') {
 		if s.characterType == EOF {
-			return nil, errors.New("UnmatchedQuoteInString")
+			return nil, s.scanError("UnmatchedQuoteInString")
 		}
 		s.buffer.WriteRune(s.currentCharacter)
 		s.step()
 	}
 
-	return &LiteralToken{&ValueToken{&Token{s.tokenStart}, s.buffer.String(), STRING}, s.previousStepPosition()}, nil
+	return &LiteralToken{&ValueToken{&Token{sourcePointer: s.tokenStart}, s.buffer.String(), STRING}, s.previousStepPosition()}, nil
 
 }
 
+// scanCharacter scans a character literal like $a. Any character can follow $, including a separator or a quote.
+func (s *Scanner) scanCharacter() (*LiteralToken, error) {
+	s.step()
+	if s.characterType == EOF {
+		return nil, s.scanError("character expected after $")
+	}
+	character := s.currentCharacter
+	s.step()
+	return NewLiteralToken(s.tokenStart, s.previousStepPosition(), string(character), CHARACTER), nil
+}
+
 func (s *Scanner) scanStringSymbol() (*LiteralToken, error) {
 	return s.scanLiteralString()
 }
 
 func (s *Scanner) scanLiteral() (TokenInterface, error) {
 	s.step()
+	if s.characterType == ALPHABET {
+		return s.scanSymbol(), nil
+	}
 	if s.characterType == BIN {
 		binary := s.scanBinaryInLiteral()
 		return binary, nil
 	}
 	if s.currentCharacter == '\'' {
-		return s.scanLiteralString()
+		symbol, err := s.scanLiteralString()
+		if err != nil {
+			return nil, err
+		}
+		symbol.valueType = SYMBOL
+		return symbol, nil
 	}
 	if s.currentCharacter == '(' || s.currentCharacter == '[' {
 		return s.scanLiteralArrayToken(), nil
 	}
-	return nil, nil
+	return nil, s.scanError("Expecting a literal type")
 }
 
 func (s *Scanner) scanLiteralArrayToken() *LiteralArrayToken {
 	valueString := string('#') + string(s.currentCharacter)
-	token := &LiteralArrayToken{&ValueToken{&Token{s.tokenStart}, valueString, ARRAY}}
+	token := &LiteralArrayToken{&ValueToken{&Token{sourcePointer: s.tokenStart}, valueString, ARRAY}}
 	s.step()
 	return token
 }
 
 func NewLiteralToken(start int64, stop int64, value string, valueType string) *LiteralToken {
-	return &LiteralToken{&ValueToken{&Token{start}, value, valueType}, stop}
+	return &LiteralToken{&ValueToken{&Token{sourcePointer: start}, value, valueType}, stop}
 }
 
 func NewBinarySelectorToken(start int64, value string) *BinarySelectorToken {
-	return &BinarySelectorToken{&ValueToken{&Token{start}, value, BIN}}
+	return &BinarySelectorToken{&ValueToken{&Token{sourcePointer: start}, value, BIN}}
 }
 
 type TokenInterface interface {
@@ -544,10 +758,13 @@ type TokenInterface interface {
 	IsLiteralArrayToken() bool
 	IsKeyword() bool
 	IsForByteArray() bool
+	GetComments() []*Comment
+	SetComments([]*Comment)
 }
 
 type Token struct {
 	sourcePointer int64
+	comments      []*Comment
 }
 
 func (t *Token) length() int64 {
@@ -570,6 +787,15 @@ func (t *Token) GetStop() int64 {
 	return t.GetStart() + t.length() - 1
 }
 
+// GetComments answers comments which precede the token in the source code
+func (t *Token) GetComments() []*Comment {
+	return t.comments
+}
+
+func (t *Token) SetComments(comments []*Comment) {
+	t.comments = comments
+}
+
 func (t *Token) IsBinary() bool {
 	return false
 }
@@ -693,6 +919,20 @@ func (m *MultiKeywordLiteralToken) IsMultiKeyword() bool {
 
 type NumberLiteralToken struct {
 	*LiteralToken
+	exactValue string
+}
+
+// SetExactValue sets the digits of the number which are evaluated instead of the value of the token
+func (t *NumberLiteralToken) SetExactValue(value string) {
+	t.exactValue = value
+}
+
+// ExactValue answers the digits of the number exactly as they were read
+func (t *NumberLiteralToken) ExactValue() string {
+	if t.exactValue == "" {
+		return t.ValueOfToken()
+	}
+	return t.exactValue
 }
 
 type BinarySelectorToken struct {
@@ -194,3 +194,203 @@ func TestScanArray(t *testing.T) {
 	testutils.ASSERT_STREQ(t, eofToken.TypeOfToken(), "EOFToken")
 
 }
+
+func TestScanComments(t *testing.T) {
+	inputString := `"speed" x "in km/h"
+	+ 1 "last"`
+	vwReader := talkio.NewReader(inputString)
+	vwScanner := New(*vwReader)
+	token, err := vwScanner.Next()
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), "x")
+	testutils.ASSERT_EQ(t, len(token.GetComments()), 1)
+	testutils.ASSERT_STREQ(t, token.GetComments()[0].Value, "speed")
+	testutils.ASSERT_EQ(t, int(token.GetComments()[0].Start), 1)
+	testutils.ASSERT_EQ(t, int(token.GetComments()[0].Stop), 7)
+
+	token, _ = vwScanner.Next()
+	testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), "+")
+	testutils.ASSERT_STREQ(t, token.GetComments()[0].Value, "in km/h")
+	token, _ = vwScanner.Next()
+	testutils.ASSERT_EQ(t, len(token.GetComments()), 0)
+	eofToken, _ := vwScanner.Next()
+	testutils.ASSERT_STREQ(t, eofToken.TypeOfToken(), "EOFToken")
+	testutils.ASSERT_STREQ(t, eofToken.GetComments()[0].Value, "last")
+}
+
+func TestScanUnterminatedComment(t *testing.T) {
+	inputString := `x + "no end`
+	vwReader := talkio.NewReader(inputString)
+	vwScanner := New(*vwReader)
+	vwScanner.Next()
+	token, err := vwScanner.Next()
+	testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), "+")
+	testutils.ASSERT_TRUE(t, err == nil)
+	_, err = vwScanner.Next()
+	testutils.ASSERT_TRUE(t, err != nil)
+	testutils.ASSERT_EQ(t, int(err.(*ScanError).Position), 5)
+}
+
+func TestScanSymbols(t *testing.T) {
+	inputString := `#foo #at:put: #+ #'hello world' #at:put`
+	vwReader := talkio.NewReader(inputString)
+	vwScanner := New(*vwReader)
+	for _, expected := range []string{"foo", "at:put:", "+", "hello world", "at:"} {
+		token, err := vwScanner.Next()
+		testutils.ASSERT_TRUE(t, err == nil)
+		testutils.ASSERT_STREQ(t, token.TypeOfToken(), SYMBOL)
+		testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), expected)
+	}
+	token, _ := vwScanner.Next()
+	testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), "put")
+
+	vwScanner = New(*talkio.NewReader(`# foo`))
+	_, err := vwScanner.Next()
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestScanIntegerAndFloatNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`42`, "42"},
+		{`123456789012345678901234567890`, "123456789012345678901234567890"},
+		{`2e3`, "2000"},
+		{`2.0`, "2.0"},
+		{`1.5e2`, "150.0"},
+		{`2d`, "2.0"},
+		{`3.14s2`, "3.14s2"},
+		{`3.14s`, "3.14s2"},
+		{`3s2`, "3s2"},
+		{`0.125s2`, "0.125s2"},
+	}
+	for _, test := range tests {
+		vwScanner := New(*talkio.NewReader(test.input))
+		token, _ := vwScanner.Next()
+		testutils.ASSERT_STREQ(t, NUMBER, token.TypeOfToken())
+		testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), test.expected)
+	}
+}
+
+// radix and exponent literals are read like in Pharo with two differences: 1e-3 is the float 0.001 like in VisualWorks,
+// Pharo reads the fraction 1/1000, and lowercase digits like in 16rff are a scan error (see TestScanWrongRadix),
+// because a lowercase letter after the digits is an exponent, a float suffix or a scale.
+func TestScanRadixAndExponentLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`16rFF`, "255"},
+		{`2r1010`, "10"},
+		{`-16r1F`, "-31"},
+		{`16r-1F`, "-31"},
+		{`36rZZ`, "1295"},
+		{`8r777`, "511"},
+		{`16r1F.8`, "31.5"},
+		{`2r1.1`, "1.5"},
+		{`2r1e4`, "16"},
+		{`16rFFs2`, "255s2"},
+		{`1e-3`, "0.001"},
+		{`1.5e-2`, "0.015"},
+		{`-2.5e3`, "-2500.0"},
+		{`1e10`, "10000000000"},
+		{`1.5q`, "1.5"},
+		{`2q`, "2.0"},
+		{`2d2`, "200.0"},
+		{`0.5`, "0.5"},
+	}
+	for _, test := range tests {
+		vwScanner := New(*talkio.NewReader(test.input))
+		token, err := vwScanner.Next()
+		testutils.ASSERT_TRUE(t, err == nil)
+		testutils.ASSERT_STREQ(t, NUMBER, token.TypeOfToken())
+		testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), test.expected)
+	}
+}
+
+func TestScanNumberFollowedByMessage(t *testing.T) {
+	tests := []struct {
+		input    string
+		number   string
+		selector string
+	}{
+		{`2e`, "2", "e"},
+		{`2sqrt`, "2", "sqrt"},
+		{`3do`, "3", "do"},
+		{`16rFFabs`, "255", "abs"},
+	}
+	for _, test := range tests {
+		vwScanner := New(*talkio.NewReader(test.input))
+		token, _ := vwScanner.Next()
+		testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), test.number)
+		token, _ = vwScanner.Next()
+		testutils.ASSERT_STREQ(t, IDENT, token.TypeOfToken())
+		testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), test.selector)
+	}
+}
+
+func TestScanWrongRadix(t *testing.T) {
+	for _, input := range []string{`37r1`, `1r0`, `16rff`} {
+		vwScanner := New(*talkio.NewReader(input))
+		_, err := vwScanner.Next()
+		testutils.ASSERT_TRUE(t, err != nil)
+	}
+	// digits after 9 are uppercase, because e, d, q and s after the digits are the exponent, the float suffixes and the scale
+	vwScanner := New(*talkio.NewReader(`16rff`))
+	_, err := vwScanner.Next()
+	testutils.ASSERT_STREQ(t, err.Error(), "scan error at 1: digits after 9 must be uppercase letters like in 16rFF")
+	vwScanner = New(*talkio.NewReader(`16rFF 16r1e2`))
+	for _, expected := range []string{"255", "256"} {
+		token, _ := vwScanner.Next()
+		testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), expected)
+	}
+}
+
+func TestScanTooBigExponent(t *testing.T) {
+	vwScanner := New(*talkio.NewReader(`x := 1e999999999`))
+	vwScanner.Next()
+	vwScanner.Next()
+	_, err := vwScanner.Next()
+	scanError, ok := err.(*ScanError)
+	testutils.ASSERT_TRUE(t, ok)
+	testutils.ASSERT_EQ(t, int(scanError.Position), 8)
+	testutils.ASSERT_STREQ(t, scanError.Message, "exponent must not be greater than 10000")
+
+	for _, input := range []string{`1e99999999999999999999999`, `2r1e-99999`, `1.5s99999`} {
+		vwScanner = New(*talkio.NewReader(input))
+		_, err = vwScanner.Next()
+		testutils.ASSERT_TRUE(t, err != nil)
+	}
+	vwScanner = New(*talkio.NewReader(`1e10000`))
+	token, err := vwScanner.Next()
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_EQ(t, len(token.(ValueTokenInterface).ValueOfToken()), 10001)
+}
+
+func TestScanCharacters(t *testing.T) {
+	vwScanner := New(*talkio.NewReader(`$a $  $' $$ #($b)`))
+	for _, expected := range []string{"a", " ", "'", "$"} {
+		token, _ := vwScanner.Next()
+		testutils.ASSERT_STREQ(t, CHARACTER, token.TypeOfToken())
+		testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), expected)
+	}
+	token, _ := vwScanner.Next()
+	testutils.ASSERT_STREQ(t, ARRAY, token.TypeOfToken())
+	token, _ = vwScanner.Next()
+	testutils.ASSERT_STREQ(t, CHARACTER, token.TypeOfToken())
+	testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), "b")
+
+	_, err := New(*talkio.NewReader(`$`)).Next()
+	testutils.ASSERT_TRUE(t, err != nil)
+}
+
+func TestScanTrailingMinus(t *testing.T) {
+	vwScanner := New(*talkio.NewReader(`3 -`))
+	token, _ := vwScanner.Next()
+	testutils.ASSERT_STREQ(t, NUMBER, token.TypeOfToken())
+	token, err := vwScanner.Next()
+	testutils.ASSERT_TRUE(t, err == nil)
+	testutils.ASSERT_STREQ(t, BIN, token.TypeOfToken())
+	testutils.ASSERT_STREQ(t, token.(ValueTokenInterface).ValueOfToken(), "-")
+}
@@ -0,0 +1,447 @@
+package treeNodes
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Method implements a message for its receiver. Message tables keep methods, so sending a message
+// is a map lookup and a direct function call without reflection.
+type Method func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error)
+
+// sendError is implemented by errors which are raised by methods and get the details of the message
+// send from the place where the message was sent
+type sendError interface {
+	error
+	setSend(selector string, receiverType string, position int64)
+}
+
+// selectorMethods are the methods for one selector of every type which has a message table. A message node
+// gets them for its selector when it is parsed, so a send looks up only the receiver type. Registering or
+// removing a method replaces the map, so evaluation never changes parsed nodes and reads the map without a lock.
+type selectorMethods struct {
+	selector string
+	methods  atomic.Value // map[string]Method by type name
+}
+
+// resolvedSelectors keep methods by selector for all parsed message nodes. It is guarded by methodTablesLock
+// and grows with the number of different selectors in parsed programs.
+var resolvedSelectors = map[string]*selectorMethods{}
+
+// resolveSelector answers the methods which messages with selector send
+func resolveSelector(selector string) *selectorMethods {
+	methodTablesLock.RLock()
+	resolved, ok := resolvedSelectors[selector]
+	methodTablesLock.RUnlock()
+	if ok {
+		return resolved
+	}
+	methodTablesLock.Lock()
+	defer methodTablesLock.Unlock()
+	if resolved, ok = resolvedSelectors[selector]; !ok {
+		resolved = &selectorMethods{selector: selector}
+		resolved.update()
+		resolvedSelectors[selector] = resolved
+	}
+	return resolved
+}
+
+// update collects the methods for the selector again. Callers hold methodTablesLock.
+func (s *selectorMethods) update() {
+	methods := map[string]Method{}
+	for typeName, table := range methodTables {
+		if method, ok := table[s.selector]; ok {
+			methods[typeName] = method
+		}
+	}
+	s.methods.Store(methods)
+}
+
+// methodFor answers the method for receivers of typeName or nil
+func (s *selectorMethods) methodFor(typeName string) Method {
+	return s.methods.Load().(map[string]Method)[typeName]
+}
+
+// Send sends the message with selector and args to receiver the same way a message expression does
+func Send(receiver SmalltalkObjectInterface, selector string, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	result, err := receiver.Perform(selector, args)
+	if err != nil {
+		if detailed, ok := err.(sendError); ok {
+			detailed.setSend(selector, receiver.TypeOf(), 0)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// scopedMethod implements a message which needs the scope of the message expression, like a class definition
+// which makes the new class a variable of the evaluation
+type scopedMethod func(scope *Scope, receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error)
+
+// classScopedMessages are the class side methods of Object which get the scope, objectScopedMessages are the
+// methods of all objects which get it. Message expressions send them instead of the methods without a scope,
+// and perform: passes its scope on to the message it sends.
+var classScopedMessages map[string]scopedMethod
+var objectScopedMessages map[string]scopedMethod
+
+// scopedMethodFor answers the scoped method for selector or nil if receiver has a method of its own for it
+func scopedMethodFor(receiver SmalltalkObjectInterface, selector string) scopedMethod {
+	if classScopedMessages[selector] == nil && objectScopedMessages[selector] == nil {
+		return nil
+	}
+	switch typedReceiver := receiver.(type) {
+	case *SmalltalkClass:
+		if owner := typedReceiver.messageOwner(selector); owner != nil {
+			if owner == ObjectClass {
+				return classScopedMessages[selector]
+			}
+			return nil
+		}
+	case *SmalltalkInstance:
+		if typedReceiver.class.methodFor(selector) != nil {
+			return nil
+		}
+	case *SmalltalkException:
+		if typedReceiver.class.methodFor(selector) != nil {
+			return nil
+		}
+	default:
+		methodTablesLock.RLock()
+		_, ok := methodTables[receiver.TypeOf()][selector]
+		methodTablesLock.RUnlock()
+		if ok {
+			return nil
+		}
+	}
+	if lookupMethod(nil, selector) == nil {
+		return nil
+	}
+	return objectScopedMessages[selector]
+}
+
+// sendIn sends the message like Send, but scoped methods get scope. Without a scope it is Send.
+func sendIn(scope *Scope, receiver SmalltalkObjectInterface, selector string, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if scope == nil {
+		return Send(receiver, selector, args)
+	}
+	receiver = evaluatedIn(scope, receiver, selector)
+	method := scopedMethodFor(receiver, selector)
+	if method == nil {
+		return Send(receiver, selector, args)
+	}
+	args, err := deferredValues(args)
+	if err != nil {
+		return nil, err
+	}
+	result, err := method(scope, receiver, args)
+	if err != nil {
+		if detailed, ok := err.(sendError); ok {
+			detailed.setSend(selector, receiver.TypeOf(), 0)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// send calls the method which the selector of the message node was resolved to for the receiver type.
+// Objects without a message table handle messages in Perform.
+func (m *MessageNode) send(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface, scope *Scope) (SmalltalkObjectInterface, error) {
+	receiver = evaluatedIn(scope, receiver, m.selectorName)
+	if scoped := scopedMethodFor(receiver, m.selectorName); scoped != nil {
+		args, err := deferredValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return scoped(scope, receiver, args)
+	}
+	var method Method
+	if m.methods != nil {
+		method = m.methods.methodFor(receiver.TypeOf())
+	}
+	if method == nil {
+		return receiver.Perform(m.selectorName, args)
+	}
+	args, err := deferredValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return method(receiver, args)
+}
+
+// blockEvaluationSelectors are the messages which evaluate their receiver block
+var blockEvaluationSelectors = map[string]bool{
+	`value`:                    true,
+	`value:`:                   true,
+	`value:value:`:             true,
+	`value:value:value:`:       true,
+	`value:value:value:value:`: true,
+	`valueWithArguments:`:      true,
+	`cull:`:                    true,
+	`cull:cull:`:               true,
+	`whileTrue:`:               true,
+	`whileFalse:`:              true,
+	`whileTrue`:                true,
+	`whileFalse`:               true,
+	`repeat`:                   true,
+	`ensure:`:                  true,
+	`ifCurtailed:`:             true,
+	`on:do:`:                   true,
+}
+
+// evaluatedIn answers the receiver of a message which evaluates a block with the on:do: messages around scope,
+// see SmalltalkBlock.withHandlers. Other receivers are answered as they are.
+func evaluatedIn(scope *Scope, receiver SmalltalkObjectInterface, selector string) SmalltalkObjectInterface {
+	block, ok := receiver.(*SmalltalkBlock)
+	if !ok || !blockEvaluationSelectors[selector] {
+		return receiver
+	}
+	handlers := scope.activeHandlers()
+	if handlers == nil {
+		return receiver
+	}
+	return block.withHandlers(handlers)
+}
+
+// respondingObject is an object whose Perform looks up methods outside of methodTables. It answers
+// whether Perform finds a method for selector, so respondsTo: agrees with the dispatch.
+type respondingObject interface {
+	respondsTo(selector string) bool
+}
+
+func performMethod(receiver SmalltalkObjectInterface, table map[string]Method, selector string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	method := lookupMethod(table, selector)
+	if method == nil {
+		if booleanControlSelectors[selector] {
+			return nil, &NonBooleanReceiverError{Selector: selector, ReceiverType: receiver.TypeOf()}
+		}
+		return nil, &DoesNotUnderstandError{Selector: selector, ReceiverType: receiver.TypeOf()}
+	}
+	args, err := deferredValues(params)
+	if err != nil {
+		return nil, err
+	}
+	return method(receiver, args)
+}
+
+// booleanControlSelectors are the messages which Pharo inlines for booleans, so sending them to other objects
+// is a NonBooleanReceiver error instead of doesNotUnderstand
+var booleanControlSelectors = map[string]bool{
+	`ifTrue:`:         true,
+	`ifFalse:`:        true,
+	`ifTrue:ifFalse:`: true,
+	`ifFalse:ifTrue:`: true,
+	`and:`:            true,
+	`or:`:             true,
+}
+
+// lookupMethod answers the method for selector from table or, if there is no such method,
+// the method which all objects understand
+func lookupMethod(table map[string]Method, selector string) Method {
+	methodTablesLock.RLock()
+	defer methodTablesLock.RUnlock()
+	if method, ok := table[selector]; ok {
+		return method
+	}
+	return objectMessages[selector]
+}
+
+// deferredValues answers params where every deferred object is replaced with its value
+func deferredValues(params []SmalltalkObjectInterface) ([]SmalltalkObjectInterface, error) {
+	var values []SmalltalkObjectInterface
+	for i, each := range params {
+		if each == nil || each.TypeOf() != DEFERRED {
+			continue
+		}
+		if values == nil {
+			values = append(values, params...)
+		}
+		deferredValue, err := valueOf(each)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = deferredValue
+	}
+	if values == nil {
+		return params, nil
+	}
+	return values, nil
+}
+
+// methodFrom answers method itself or a method which calls function of any other signature through reflection
+func methodFrom(selector string, function interface{}) (Method, error) {
+	switch typedFunction := function.(type) {
+	case Method:
+		return typedFunction, nil
+	case func(SmalltalkObjectInterface, []SmalltalkObjectInterface) (SmalltalkObjectInterface, error):
+		return typedFunction, nil
+	}
+	err := checkMethodSignature(selector, function)
+	if err != nil {
+		return nil, err
+	}
+	reflectedFunction := reflect.ValueOf(function)
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		return callReflected(reflectedFunction, selector, receiver, args)
+	}, nil
+}
+
+func checkArgumentsCount(args []SmalltalkObjectInterface, count int) error {
+	if len(args) != count {
+		return &WrongArgumentCountError{Expected: count, Actual: len(args)}
+	}
+	return nil
+}
+
+func typeMismatch(expected reflect.Type, actual SmalltalkObjectInterface) error {
+	return &TypeMismatchError{Expected: typeNameOf(expected), Actual: actual.TypeOf()}
+}
+
+// adapters of typed primitives to methods
+
+func unaryMethod[R SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R) T) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		err := checkArgumentsCount(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		typedReceiver, ok := receiver.(R)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(0), receiver)
+		}
+		return function(typedReceiver), nil
+	}
+}
+
+func unaryMethodE[R SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R) (T, error)) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		err := checkArgumentsCount(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		typedReceiver, ok := receiver.(R)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(0), receiver)
+		}
+		result, err := function(typedReceiver)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+func binaryMethod[R SmalltalkObjectInterface, A SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R, A) T) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		err := checkArgumentsCount(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		typedReceiver, ok := receiver.(R)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(0), receiver)
+		}
+		typedArg, ok := args[0].(A)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(1), args[0])
+		}
+		return function(typedReceiver, typedArg), nil
+	}
+}
+
+func binaryMethodE[R SmalltalkObjectInterface, A SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R, A) (T, error)) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		err := checkArgumentsCount(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		typedReceiver, ok := receiver.(R)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(0), receiver)
+		}
+		typedArg, ok := args[0].(A)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(1), args[0])
+		}
+		result, err := function(typedReceiver, typedArg)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+func ternaryMethod[R SmalltalkObjectInterface, A SmalltalkObjectInterface, B SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R, A, B) T) Method {
+	return ternaryMethodE(func(receiver R, firstArg A, secondArg B) (T, error) {
+		return function(receiver, firstArg, secondArg), nil
+	})
+}
+
+func ternaryMethodE[R SmalltalkObjectInterface, A SmalltalkObjectInterface, B SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R, A, B) (T, error)) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		err := checkArgumentsCount(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		typedReceiver, ok := receiver.(R)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(0), receiver)
+		}
+		firstArg, ok := args[0].(A)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(1), args[0])
+		}
+		secondArg, ok := args[1].(B)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(2), args[1])
+		}
+		result, err := function(typedReceiver, firstArg, secondArg)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+func quaternaryMethodE[R SmalltalkObjectInterface, A SmalltalkObjectInterface, B SmalltalkObjectInterface, C SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R, A, B, C) (T, error)) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		err := checkArgumentsCount(args, 3)
+		if err != nil {
+			return nil, err
+		}
+		typedReceiver, ok := receiver.(R)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(0), receiver)
+		}
+		firstArg, ok := args[0].(A)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(1), args[0])
+		}
+		secondArg, ok := args[1].(B)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(2), args[1])
+		}
+		thirdArg, ok := args[2].(C)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(3), args[2])
+		}
+		result, err := function(typedReceiver, firstArg, secondArg, thirdArg)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+func variadicMethodE[R SmalltalkObjectInterface, T SmalltalkObjectInterface](function func(R, []SmalltalkObjectInterface) (T, error)) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		typedReceiver, ok := receiver.(R)
+		if !ok {
+			return nil, typeMismatch(reflect.TypeOf(function).In(0), receiver)
+		}
+		result, err := function(typedReceiver, args)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
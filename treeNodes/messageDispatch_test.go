@@ -0,0 +1,128 @@
+package treeNodes
+
+import (
+	"testing"
+)
+
+func TestSendThroughMethodTable(t *testing.T) {
+	result, err := Send(NewSmalltalkNumber(3), "+", []SmalltalkObjectInterface{NewSmalltalkNumber(4)})
+	if err != nil || result.(*SmalltalkNumber).GetValue() != 7 {
+		t.Errorf("3 + 4 answered %v, %v", result, err)
+	}
+	_, err = Send(NewSmalltalkNumber(3), "+", []SmalltalkObjectInterface{NewSmalltalkString("4")})
+	mismatch, ok := err.(*TypeMismatchError)
+	if !ok || mismatch.Selector != "+" || mismatch.Expected != NUMBER_OBJ || mismatch.Actual != STRING_OBJ {
+		t.Errorf("3 + '4' answered %v", err)
+	}
+	_, err = Send(NewSmalltalkNumber(3), "foo", nil)
+	dnu, ok := err.(*DoesNotUnderstandError)
+	if !ok || dnu.Selector != "foo" || dnu.ReceiverType != NUMBER_OBJ {
+		t.Errorf("3 foo answered %v", err)
+	}
+}
+
+func TestReflectedMethod(t *testing.T) {
+	method, err := methodFrom("+", plus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := method(NewSmalltalkNumber(3), []SmalltalkObjectInterface{NewSmalltalkNumber(4)})
+	if err != nil || result.(*SmalltalkNumber).GetValue() != 7 {
+		t.Errorf("3 + 4 answered %v, %v", result, err)
+	}
+	_, err = method(NewSmalltalkNumber(3), []SmalltalkObjectInterface{NewSmalltalkBoolean(true)})
+	if _, ok := err.(*TypeMismatchError); !ok {
+		t.Errorf("3 + true answered %v", err)
+	}
+}
+
+func TestCallRejectsIncompatibleArguments(t *testing.T) {
+	messages := map[string]interface{}{
+		`+`:       plus,
+		`broken`:  42,
+		`nothing`: func(receiver *SmalltalkNumber) *SmalltalkNumber { return nil },
+	}
+	receiver := NewSmalltalkNumber(3)
+	_, err := Call(receiver, messages, `+`, []SmalltalkObjectInterface{NewSmalltalkString("4")})
+	if _, ok := err.(*TypeMismatchError); !ok {
+		t.Errorf("3 + '4' answered %v", err)
+	}
+	_, err = Call(receiver, messages, `+`, []SmalltalkObjectInterface{nil})
+	if _, ok := err.(*TypeMismatchError); !ok {
+		t.Errorf("3 + nil answered %v", err)
+	}
+	_, err = Call(receiver, messages, `+`, nil)
+	if _, ok := err.(*WrongArgumentCountError); !ok {
+		t.Errorf("3 + answered %v", err)
+	}
+	_, err = Call(nil, messages, `+`, []SmalltalkObjectInterface{receiver})
+	if _, ok := err.(*TypeMismatchError); !ok {
+		t.Errorf("nil receiver answered %v", err)
+	}
+	_, err = Call(receiver, messages, `broken`, nil)
+	if err == nil {
+		t.Errorf("a method which is not a function answered no error")
+	}
+	result, err := Call(receiver, messages, `nothing`, nil)
+	if err != nil || result.TypeOf() != UNDEFINED_OBJ {
+		t.Errorf("a method answering nil answered %v, %v", result, err)
+	}
+}
+
+func TestResolvedSelector(t *testing.T) {
+	resolved := resolveSelector(`+`)
+	if resolved != resolveSelector(`+`) || resolved.methodFor(NUMBER_OBJ) == nil || resolved.methodFor(BOOLEAN_OBJ) != nil {
+		t.Errorf("#+ was not resolved to the method of numbers")
+	}
+	err := RegisterMethod(BOOLEAN_OBJ, `+`, func(receiver *SmalltalkBoolean, arg *SmalltalkBoolean) *SmalltalkBoolean {
+		return NewSmalltalkBoolean(receiver.GetValue() || arg.GetValue())
+	})
+	if err != nil || resolved.methodFor(BOOLEAN_OBJ) == nil {
+		t.Errorf("registered method is not resolved: %v", err)
+	}
+	RemoveMethod(BOOLEAN_OBJ, `+`)
+	if resolved.methodFor(BOOLEAN_OBJ) != nil {
+		t.Errorf("removed method is still resolved")
+	}
+}
+
+var benchmarkResult SmalltalkObjectInterface
+
+func BenchmarkReflectionCall(b *testing.B) {
+	messages := map[string]interface{}{`+`: plus}
+	receiver := NewSmalltalkNumber(3)
+	args := []SmalltalkObjectInterface{NewSmalltalkNumber(4)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult, _ = Call(receiver, messages, `+`, args)
+	}
+}
+
+func BenchmarkPerform(b *testing.B) {
+	receiver := NewSmalltalkNumber(3)
+	args := []SmalltalkObjectInterface{NewSmalltalkNumber(4)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult, _ = receiver.Perform(`+`, args)
+	}
+}
+
+func BenchmarkCachedMethod(b *testing.B) {
+	method := numberMessages[`+`]
+	receiver := NewSmalltalkNumber(3)
+	args := []SmalltalkObjectInterface{NewSmalltalkNumber(4)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult, _ = method(receiver, args)
+	}
+}
+
+func BenchmarkResolvedSend(b *testing.B) {
+	resolved := resolveSelector(`+`)
+	receiver := NewSmalltalkNumber(3)
+	args := []SmalltalkObjectInterface{NewSmalltalkNumber(4)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult, _ = resolved.methodFor(receiver.TypeOf())(receiver, args)
+	}
+}
@@ -0,0 +1,130 @@
+package treeNodes
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// methodTables keeps message tables of every built-in object type. Exceptions, classes and instances of classes
+// defined by scripts look up methods of their class first, so they have no table here.
+var methodTables = map[string]map[string]Method{
+	NUMBER_OBJ:             numberMessages,
+	BOOLEAN_OBJ:            booleanMessages,
+	STRING_OBJ:             stringMessages,
+	SYMBOL_OBJ:             symbolMessages,
+	CHARACTER_OBJ:          characterMessages,
+	BLOCK_OBJ:              blockMessages,
+	ARRAY_OBJ:              arrayMessages,
+	BYTE_ARRAY_OBJ:         byteArrayMessages,
+	INTERVAL_OBJ:           intervalMessages,
+	ORDERED_COLLECTION_OBJ: orderedCollectionMessages,
+	SET_OBJ:                setMessages,
+	BAG_OBJ:                bagMessages,
+	DICTIONARY_OBJ:         dictionaryMessages,
+	CONTEXT_OBJ:            contextMessages,
+	UNDEFINED_OBJ:          undefinedMessages,
+	OBJECT_OBJ:             objectMessages,
+}
+
+// methodTablesLock guards message tables of methodTables and the methods of classes defined by scripts, so methods
+// are registered, removed and filed in while evaluators send messages on other goroutines
+var methodTablesLock sync.RWMutex
+
+var (
+	objectInterfaceType = reflect.TypeOf((*SmalltalkObjectInterface)(nil)).Elem()
+	errorInterfaceType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterMethod adds function to the message table of objects of typeName (NUMBER_OBJ, STRING_OBJ etc.)
+// or replaces the built-in method with the same selector. Methods of OBJECT_OBJ are understood by all objects. The function receives the receiver and one
+// parameter per selector argument, and answers a smalltalk object and optionally an error, e.g.
+//
+//	func clampToAnd(receiver *SmalltalkNumber, min *SmalltalkNumber, max *SmalltalkNumber) *SmalltalkNumber
+//
+// Such functions are called through reflection. A Method is called directly, which is faster.
+//
+// Message tables are shared by all evaluators. A method registered while evaluators run is sent by messages
+// which are evaluated after the registration.
+func RegisterMethod(typeName string, selector string, function interface{}) error {
+	method, err := methodFrom(selector, function)
+	if err != nil {
+		return err
+	}
+	methodTablesLock.Lock()
+	defer methodTablesLock.Unlock()
+	table, ok := methodTables[typeName]
+	if !ok {
+		return errors.New(`we do not have message table for "` + typeName + `" objects`)
+	}
+	table[selector] = method
+	if resolved, ok := resolvedSelectors[selector]; ok {
+		resolved.update()
+	}
+	return nil
+}
+
+// RemoveMethod removes selector from the message table of typeName objects, so they do not understand it anymore
+func RemoveMethod(typeName string, selector string) {
+	methodTablesLock.Lock()
+	defer methodTablesLock.Unlock()
+	table, ok := methodTables[typeName]
+	if ok {
+		delete(table, selector)
+		if resolved, ok := resolvedSelectors[selector]; ok {
+			resolved.update()
+		}
+	}
+}
+
+// LookupMethod answers the method registered for selector in the message table of typeName objects
+func LookupMethod(typeName string, selector string) (Method, bool) {
+	methodTablesLock.RLock()
+	defer methodTablesLock.RUnlock()
+	table, ok := methodTables[typeName]
+	if !ok {
+		return nil, false
+	}
+	method, ok := table[selector]
+	return method, ok
+}
+
+func checkMethodSignature(selector string, function interface{}) error {
+	if function == nil {
+		return errors.New("method for #" + selector + " should be a function")
+	}
+	functionType := reflect.TypeOf(function)
+	if functionType.Kind() != reflect.Func {
+		return errors.New("method for #" + selector + " should be a function")
+	}
+	if functionType.IsVariadic() || functionType.NumIn() != SelectorArity(selector)+1 {
+		return fmt.Errorf("method for #%s should have receiver and %d parameters", selector, SelectorArity(selector))
+	}
+	for i := 0; i < functionType.NumIn(); i++ {
+		if !functionType.In(i).Implements(objectInterfaceType) {
+			return fmt.Errorf("parameter %d of method for #%s is not a smalltalk object", i, selector)
+		}
+	}
+	if functionType.NumOut() < 1 || functionType.NumOut() > 2 || !functionType.Out(0).Implements(objectInterfaceType) {
+		return errors.New("method for #" + selector + " should answer a smalltalk object")
+	}
+	if functionType.NumOut() == 2 && functionType.Out(1) != errorInterfaceType {
+		return errors.New("second result of method for #" + selector + " should be an error")
+	}
+	return nil
+}
+
+// SelectorArity answers the number of arguments of messages with selector
+func SelectorArity(selector string) int {
+	if selector == "" {
+		return 0
+	}
+	first := []rune(selector)[0]
+	if unicode.IsLetter(first) || first == '_' {
+		return strings.Count(selector, ":")
+	}
+	return 1
+}
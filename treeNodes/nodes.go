@@ -15,15 +15,28 @@ type ProgramNodeInterface interface {
 	IsLiteralNode() bool
 	IsLiteralArray() bool
 	IsAssignment() bool
-	Eval(scope *Scope) SmalltalkObjectInterface
+	IsReturn() bool
+	Eval(scope *Scope) (SmalltalkObjectInterface, error)
 	GetLastValue() SmalltalkObjectInterface
 	SetLastValue(SmalltalkObjectInterface)
 	GetVariables() []string
+	GetComments() []*scanner.Comment
+	AddComments(comments []*scanner.Comment)
 }
 
 type Node struct {
 	parent    ProgramNodeInterface
 	lastValue SmalltalkObjectInterface
+	comments  []*scanner.Comment
+}
+
+// GetComments answers the source code comments which belong to the node
+func (n *Node) GetComments() []*scanner.Comment {
+	return n.comments
+}
+
+func (n *Node) AddComments(comments []*scanner.Comment) {
+	n.comments = append(n.comments, comments...)
 }
 
 func (n *Node) SetLastValue(value SmalltalkObjectInterface) {
@@ -34,8 +47,8 @@ func (n *Node) GetLastValue() SmalltalkObjectInterface {
 	return n.lastValue
 }
 
-func (n *Node) Eval(scope *Scope) SmalltalkObjectInterface {
-	return nil
+func (n *Node) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	return nil, nil
 }
 
 func (n *Node) IsMessage() bool {
@@ -46,6 +59,10 @@ func (n *Node) IsAssignment() bool {
 	return false
 }
 
+func (n *Node) IsReturn() bool {
+	return false
+}
+
 func (n *Node) IsLiteralNode() bool {
 	return false
 }
@@ -123,6 +140,42 @@ func (m *SequenceNode) GetVariables() []string {
 	return result
 }
 
+type ReturnNode struct {
+	*Node
+
+	position int64
+	value    ValueNodeInterface
+}
+
+func (r *ReturnNode) TypeOfNode() string {
+	return "ReturnNode"
+}
+
+func (r *ReturnNode) IsReturn() bool {
+	return true
+}
+
+func (r *ReturnNode) GetValue() ValueNodeInterface {
+	return r.value
+}
+
+func (r *ReturnNode) SetValue(value ValueNodeInterface) {
+	r.value = value
+	r.value.SetParent(r)
+}
+
+func (r *ReturnNode) GetPosition() int64 {
+	return r.position
+}
+
+func (r *ReturnNode) SetPosition(position int64) {
+	r.position = position
+}
+
+func (r *ReturnNode) GetVariables() []string {
+	return r.value.GetVariables()
+}
+
 type ValueNodeInterface interface {
 	ProgramNodeInterface
 	AddParenthesis(interval Interval)
@@ -200,13 +253,12 @@ func (l *LiteralNode) LiteralToken(token scanner.LiteralTokenInterface) LiteralN
 func createLiteralArrayNodeFromToken(token scanner.LiteralTokenInterface) LiteralNodeInterface {
 	startPosition := token.GetStart()
 	stopPosition := token.GetStop()
-	var contents []LiteralNodeInterface
-	//TODO: we should fill contents for LiteralArrayNode
-	return &LiteralArrayNode{&LiteralNode{NewValueNode()}, startPosition, stopPosition, contents}
+	// a token has no elements, the parser creates arrays with their elements by CreateLiteralArrayNode
+	return &LiteralArrayNode{LiteralNode: &LiteralNode{NewValueNode()}, start: startPosition, stop: stopPosition}
 }
 
+// CreateLiteralArrayNode answers the node of a literal array like #(1 $a #foo (1 2))
 func CreateLiteralArrayNode(startPosition int64, stopPosition int64, contents []LiteralNodeInterface) LiteralNodeInterface {
-	//TODO: we should fill contents for LiteralArrayNode
 	node := new(LiteralArrayNode)
 	node.LiteralNode = NewLiteralNode()
 	node.start = startPosition
@@ -218,17 +270,29 @@ func CreateLiteralArrayNode(startPosition int64, stopPosition int64, contents []
 	return node
 }
 
+// CreateLiteralByteArrayNode answers the node of a byte array like #[1 2 255]. Its contents are number literals.
+func CreateLiteralByteArrayNode(startPosition int64, stopPosition int64, contents []LiteralNodeInterface) LiteralNodeInterface {
+	node := CreateLiteralArrayNode(startPosition, stopPosition, contents).(*LiteralArrayNode)
+	node.isByteArray = true
+	return node
+}
+
 type LiteralArrayNode struct {
 	*LiteralNode
-	start    int64
-	stop     int64
-	contents []LiteralNodeInterface
+	start       int64
+	stop        int64
+	contents    []LiteralNodeInterface
+	isByteArray bool
 }
 
 func (l *LiteralArrayNode) IsLiteralArray() bool {
 	return true
 }
 
+func (l *LiteralArrayNode) IsByteArray() bool {
+	return l.isByteArray
+}
+
 func (l *LiteralArrayNode) GetValue() string {
 	var value string
 	var separator string
@@ -260,6 +324,14 @@ func (literalValue *LiteralValueNode) GetValue() string {
 	return literalValue.token.ValueOfToken()
 }
 
+// exactValue answers the digits of a number literal which keep its exact value
+func (literalValue *LiteralValueNode) exactValue() string {
+	if number, ok := literalValue.token.(*scanner.NumberLiteralToken); ok {
+		return number.ExactValue()
+	}
+	return literalValue.GetValue()
+}
+
 func (l *LiteralValueNode) GetVariables() []string {
 	return nil
 }
@@ -288,6 +360,9 @@ type MessageNode struct {
 	selector      *scanner.KeywordToken
 	selectorParts []scanner.ValueTokenInterface
 	arguments     []ValueNodeInterface
+	selectorName  string
+	superSend     bool
+	methods       *selectorMethods
 }
 
 func (m *MessageNode) GetReceiver() ValueNodeInterface {
@@ -295,11 +370,15 @@ func (m *MessageNode) GetReceiver() ValueNodeInterface {
 }
 
 func (m *MessageNode) GetSelector() string {
-	selector := ""
-	for _, each := range m.selectorParts {
-		selector = selector + each.ValueOfToken()
+	return m.selectorName
+}
+
+// GetPosition answers the source position of the first selector part
+func (m *MessageNode) GetPosition() int64 {
+	if len(m.selectorParts) == 0 {
+		return 0
 	}
-	return selector
+	return m.selectorParts[0].GetStart()
 }
 
 func (m *MessageNode) GetSelectorParts() []scanner.ValueTokenInterface {
@@ -317,12 +396,24 @@ func (n *MessageNode) IsMessage() bool {
 func (m *MessageNode) SetReceiverSelectorPartsArguments(receiver ValueNodeInterface, selectorParts []scanner.ValueTokenInterface, arguments []ValueNodeInterface) {
 	m.SetReceiver(receiver)
 	m.selectorParts = selectorParts
+	m.selectorName = ""
+	for _, each := range selectorParts {
+		m.selectorName = m.selectorName + each.ValueOfToken()
+	}
+	m.methods = resolveSelector(m.selectorName)
 	m.SetArguments(arguments)
 }
 
 func (m *MessageNode) SetReceiver(receiver ValueNodeInterface) {
 	m.receiver = receiver
 	m.receiver.SetParent(m)
+	variable, ok := receiver.(*VariableNode)
+	m.superSend = ok && variable.GetName() == `super`
+}
+
+// IsSuperSend answers whether the message is sent to super, so its method is looked up in the superclass
+func (m *MessageNode) IsSuperSend() bool {
+	return m.superSend
 }
 
 func (m *MessageNode) SetArguments(arguments []ValueNodeInterface) {
@@ -362,8 +453,19 @@ func (m *CascadeNode) SetMessages(messages []*MessageNode) {
 	}
 }
 
+func (m *CascadeNode) GetMessages() []*MessageNode {
+	return m.messages
+}
+
 func (m *CascadeNode) GetVariables() []string {
-	return nil
+	variables := m.GetReceiver().GetVariables()
+	for _, message := range m.messages {
+		for _, arg := range message.arguments {
+			variables = append(variables, arg.GetVariables()...)
+		}
+	}
+	sort.Strings(variables)
+	return variables
 }
 
 type BlockNode struct {
@@ -413,7 +515,115 @@ func (m *BlockNode) GetVariables() []string {
 	for _, arg := range m.arguments {
 		localVariable := arg.GetVariables()[0]
 		index := sort.SearchStrings(result, localVariable)
-		result = append(result[:index], result[index+1:]...)
+		// the body may not use the argument
+		if index < len(result) && result[index] == localVariable {
+			result = append(result[:index], result[index+1:]...)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// MethodNode is a method of a class defined by a script: its message pattern, like at: index put: value, and its body
+type MethodNode struct {
+	*Node
+	selector  string
+	arguments []*VariableNode
+	body      *SequenceNode
+	source    string
+}
+
+func (m *MethodNode) TypeOfNode() string {
+	return "MethodNode"
+}
+
+func (m *MethodNode) GetSelector() string {
+	return m.selector
+}
+
+func (m *MethodNode) SetSelector(selector string) {
+	m.selector = selector
+}
+
+func (m *MethodNode) GetArguments() []*VariableNode {
+	return m.arguments
+}
+
+func (m *MethodNode) SetArguments(arguments []*VariableNode) {
+	m.arguments = arguments
+	for _, arg := range arguments {
+		arg.SetParent(m)
+	}
+}
+
+func (m *MethodNode) GetBody() *SequenceNode {
+	return m.body
+}
+
+func (m *MethodNode) SetBody(body *SequenceNode) {
+	m.body = body
+	body.SetParent(m)
+}
+
+// GetVariables answers the variables of the method body which are not its arguments
+func (m *MethodNode) GetVariables() []string {
+	result := m.body.GetVariables()
+	sort.Strings(result)
+	for _, arg := range m.arguments {
+		localVariable := arg.GetVariables()[0]
+		index := sort.SearchStrings(result, localVariable)
+		if index < len(result) && result[index] == localVariable {
+			result = append(result[:index], result[index+1:]...)
+		}
+	}
+	return result
+}
+
+// GetSource answers the source code which the method was parsed from
+func (m *MethodNode) GetSource() string {
+	return m.source
+}
+
+func (m *MethodNode) SetSource(source string) {
+	m.source = source
+}
+
+// DynamicArrayNode is a brace array like {x. y. x + y}. Its statements are evaluated every time, so unlike
+// a literal array it can contain values of variables.
+type DynamicArrayNode struct {
+	*ValueNode
+	left       int64
+	right      int64
+	statements []ProgramNodeInterface
+}
+
+func (m *DynamicArrayNode) TypeOfNode() string {
+	return "DynamicArrayNode"
+}
+
+func (m *DynamicArrayNode) SetLeft(left int64) {
+	m.left = left
+}
+
+func (m *DynamicArrayNode) SetRight(right int64) {
+	m.right = right
+}
+
+func (m *DynamicArrayNode) SetStatements(statements []ProgramNodeInterface) {
+	m.statements = statements
+	for _, statement := range statements {
+		statement.SetParent(m)
+	}
+}
+
+func (m *DynamicArrayNode) GetStatements() []ProgramNodeInterface {
+	return m.statements
+}
+
+func (m *DynamicArrayNode) GetVariables() []string {
+	result := []string{}
+	for _, statement := range m.statements {
+		result = append(result, statement.GetVariables()...)
 	}
 	sort.Strings(result)
 	return result
@@ -450,6 +660,18 @@ func NewBlockNode() *BlockNode {
 	return node
 }
 
+func NewDynamicArrayNode() *DynamicArrayNode {
+	node := new(DynamicArrayNode)
+	node.ValueNode = NewValueNode()
+	return node
+}
+
+func NewMethodNode() *MethodNode {
+	node := new(MethodNode)
+	node.Node = &Node{}
+	return node
+}
+
 func NewMessageNode() *MessageNode {
 	node := new(MessageNode)
 	node.ValueNode = NewValueNode()
@@ -462,6 +684,12 @@ func NewCascadeNode() *CascadeNode {
 	return node
 }
 
+func NewReturnNode() *ReturnNode {
+	node := new(ReturnNode)
+	node.Node = &Node{}
+	return node
+}
+
 func NewValueNode() *ValueNode {
 	node := new(ValueNode)
 	node.Node = &Node{}
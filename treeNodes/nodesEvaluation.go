@@ -2,15 +2,27 @@ package treeNodes
 
 import (
 	"errors"
-	"fmt"
-	"strconv"
+	"unicode/utf8"
 
 	"github.com/SealNTibbers/GotalkInterpreter/scanner"
 )
 
 type Scope struct {
-	variables  map[string]SmalltalkObjectInterface
-	OuterScope *Scope
+	variables     map[string]SmalltalkObjectInterface
+	OuterScope    *Scope
+	context       *Context
+	numericPolicy *NumericPolicy
+	// handlers is the innermost on:do: around the activation of a block, see activeHandlers
+	handlers *handlerFrame
+	// sideEffects are recorded for the evaluations inside of the scope, see TrackSideEffects
+	sideEffects *sideEffects
+}
+
+type sideEffects struct {
+	// stateful is set by messages to objects with state of their own
+	stateful bool
+	// definedClass is set by class definitions
+	definedClass bool
 }
 
 func (s *Scope) Initialize() *Scope {
@@ -19,10 +31,25 @@ func (s *Scope) Initialize() *Scope {
 }
 
 func (s *Scope) SetVar(name string, value SmalltalkObjectInterface) SmalltalkObjectInterface {
+	if value == nil {
+		value = NewSmalltalkUndefinedObject()
+	}
 	s.variables[name] = value
 	return value
 }
 
+// Assign sets the variable in the nearest scope of the evaluation which has it, so blocks change
+// variables of the scopes they were created in. Otherwise the variable is created in this scope.
+// Scopes without a context, like the global scope of an evaluator, are never changed by assignments.
+func (s *Scope) Assign(name string, value SmalltalkObjectInterface) SmalltalkObjectInterface {
+	for scope := s; scope != nil && scope.context != nil; scope = scope.OuterScope {
+		if _, ok := scope.variables[name]; ok {
+			return scope.SetVar(name, value)
+		}
+	}
+	return s.SetVar(name, value)
+}
+
 func (s *Scope) SetStringVar(name string, value string) *SmalltalkString {
 	smValue := NewSmalltalkString(value)
 	return s.SetVar(name, smValue).(*SmalltalkString)
@@ -33,11 +60,110 @@ func (s *Scope) SetNumberVar(name string, value float64) *SmalltalkNumber {
 	return s.SetVar(name, smValue).(*SmalltalkNumber)
 }
 
+func (s *Scope) SetIntegerVar(name string, value int64) *SmalltalkNumber {
+	smValue := NewSmalltalkInteger(value)
+	return s.SetVar(name, smValue).(*SmalltalkNumber)
+}
+
 func (s *Scope) SetBoolVar(name string, value bool) *SmalltalkBoolean {
 	smValue := NewSmalltalkBoolean(value)
 	return s.SetVar(name, smValue).(*SmalltalkBoolean)
 }
 
+func (s *Scope) SetContext(context *Context) *Scope {
+	s.context = context
+	return s
+}
+
+// GetContext answers the activation that a ^ evaluated in this scope returns from.
+func (s *Scope) GetContext() *Context {
+	for scope := s; scope != nil; scope = scope.OuterScope {
+		if scope.context != nil {
+			return scope.context
+		}
+	}
+	return nil
+}
+
+// TrackSideEffects makes the scope record whether evaluations inside of it send messages to classes, instances
+// of classes defined by scripts, exceptions, collections or Go objects, and whether they define classes. Results of messages
+// to objects with state of their own may change without the variables of the program, so they are not cached.
+func (s *Scope) TrackSideEffects() *Scope {
+	s.sideEffects = &sideEffects{}
+	return s
+}
+
+// HasSideEffects answers whether evaluations inside of the scope sent messages to objects with state of their own
+func (s *Scope) HasSideEffects() bool {
+	return s.sideEffects != nil && s.sideEffects.stateful
+}
+
+// DefinedClass answers whether evaluations inside of the scope defined classes
+func (s *Scope) DefinedClass() bool {
+	return s.sideEffects != nil && s.sideEffects.definedClass
+}
+
+// trackedSideEffects answers the side effects of the nearest scope which tracks them or nil
+func (s *Scope) trackedSideEffects() *sideEffects {
+	for scope := s; scope != nil; scope = scope.OuterScope {
+		if scope.sideEffects != nil {
+			return scope.sideEffects
+		}
+	}
+	return nil
+}
+
+// noteSideEffects records a message to receiver with args
+func (s *Scope) noteSideEffects(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) {
+	stateful := hasState(receiver)
+	for _, each := range args {
+		stateful = stateful || hasState(each)
+	}
+	if !stateful {
+		return
+	}
+	if effects := s.trackedSideEffects(); effects != nil {
+		effects.stateful = true
+	}
+}
+
+// hasState answers whether messages to object may change state which later evaluations see, like at:put: to
+// an array or add: to a collection. Go code changes Go objects without the evaluator too.
+func hasState(object SmalltalkObjectInterface) bool {
+	switch object.(type) {
+	case *SmalltalkClass, *SmalltalkInstance, *SmalltalkException, *SmalltalkGoObject:
+		return true
+	case *SmalltalkArray, *SmalltalkByteArray, *SmalltalkOrderedCollection, *SmalltalkSet, *SmalltalkBag, *SmalltalkDictionary:
+		return true
+	}
+	return false
+}
+
+// defineClass makes class a variable of the outermost scope, so later evaluations see it. A class which is
+// defined again keeps its methods.
+func (s *Scope) defineClass(class *SmalltalkClass) *SmalltalkClass {
+	if effects := s.trackedSideEffects(); effects != nil {
+		effects.definedClass = true
+	}
+	global := s.outermost()
+	if existing, ok := global.variables[class.name].(*SmalltalkClass); ok && existing.methods != nil {
+		existing.redefine(class)
+		return existing
+	}
+	class.environment = global
+	global.SetVar(class.name, class)
+	return class
+}
+
+// outermost answers the scope which all scopes of the evaluation are inside of
+func (s *Scope) outermost() *Scope {
+	global := s
+	for global.OuterScope != nil {
+		global = global.OuterScope
+	}
+	return global
+}
+
 func (s *Scope) FindValueByName(name string) (SmalltalkObjectInterface, bool) {
 	value, ok := s.variables[name]
 	return value, ok
@@ -56,92 +182,280 @@ func (s *Scope) GetVarValue(name string) (SmalltalkObjectInterface, error) {
 	}
 }
 
-func (message *MessageNode) Eval(scope *Scope) SmalltalkObjectInterface {
-	receiver := message.receiver.Eval(scope)
+// Context is an activation of a program (or a method) which ^ returns from.
+// Blocks remember the context they were created in as their home context.
+// Methods of classes defined by scripts are activations too: self is their receiver and super sends start the
+// lookup in the superclass of their class.
+type Context struct {
+	finished    bool
+	receiver    SmalltalkObjectInterface
+	methodClass *SmalltalkClass
+	selector    string
+}
+
+// nonLocalReturn unwinds the evaluation up to the home context of a ^ statement.
+type nonLocalReturn struct {
+	home  *Context
+	value SmalltalkObjectInterface
+}
+
+func (r *nonLocalReturn) Error() string {
+	return "return statement outside of its home context"
+}
+
+// Activate evaluates program in scope as a new activation, so a ^ evaluated by the program itself
+// or by any block created during this activation returns from here.
+func Activate(program ProgramNodeInterface, scope *Scope) (SmalltalkObjectInterface, error) {
+	context := new(Context)
+	scope.SetContext(context)
+	defer func() {
+		context.finished = true
+	}()
+	result, err := program.Eval(scope)
+	if err != nil {
+		if ret, ok := err.(*nonLocalReturn); ok && ret.home == context {
+			return ret.value, nil
+		}
+		return nil, unhandledError(err)
+	}
+	return result, nil
+}
+
+func (message *MessageNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	receiver, err := message.receiver.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	return message.evalWithReceiver(receiver, scope)
+}
+
+func (message *MessageNode) evalWithReceiver(receiver SmalltalkObjectInterface, scope *Scope) (SmalltalkObjectInterface, error) {
+	if receiver == nil {
+		return nil, &DoesNotUnderstandError{message.selectorName, UNDEFINED_OBJ, message.GetPosition()}
+	}
 	var argObjects []SmalltalkObjectInterface
-	for _, each := range message.arguments {
-		argument := each.Eval(scope)
+	if len(message.arguments) > 0 {
+		argObjects = make([]SmalltalkObjectInterface, len(message.arguments))
+	}
+	for i, each := range message.arguments {
+		argument, err := each.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
 		if argument == nil {
-			each.Eval(scope)
-			return nil
-
+			return nil, &TypeMismatchError{"OBJECT", UNDEFINED_OBJ, message.selectorName, receiver.TypeOf(), message.GetPosition()}
 		}
-		argObjects = append(argObjects, argument)
+		argObjects[i] = argument
+	}
+	scope.noteSideEffects(receiver, argObjects)
+	var result SmalltalkObjectInterface
+	var err error
+	if message.superSend {
+		result, err = message.sendToSuper(receiver, argObjects, scope)
+	} else {
+		result, err = message.send(receiver, argObjects, scope)
+	}
+	if err == nil {
+		result, err = message.applyNumericPolicy(receiver, argObjects, result, scope)
 	}
-	result, err := receiver.Perform(message.GetSelector(), argObjects)
 	if err != nil {
-		fmt.Println(err)
+		if detailed, ok := err.(sendError); ok {
+			detailed.setSend(message.selectorName, receiver.TypeOf(), message.GetPosition())
+		}
+		return scope.signal(err)
 	}
-	return result
+	return result, nil
 }
 
-func (block *BlockNode) Eval(scope *Scope) SmalltalkObjectInterface {
-	return &SmalltalkBlock{&SmalltalkObject{}, block, scope}
+// sendToSuper sends the message to self, but the method is looked up in the superclass of the class which
+// defines the current method. Outside of methods super is a variable like any other.
+func (message *MessageNode) sendToSuper(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface, scope *Scope) (SmalltalkObjectInterface, error) {
+	context := scope.GetContext()
+	object, ok := receiver.(scriptObject)
+	if context == nil || context.methodClass == nil || !ok {
+		return message.send(receiver, args, scope)
+	}
+	return object.performFrom(context.methodClass.superclass, message.selectorName, args)
 }
 
-func (sequence *SequenceNode) Eval(scope *Scope) SmalltalkObjectInterface {
+func (cascade *CascadeNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	// the receiver is evaluated only once and every message of the cascade is sent to it
+	receiver, err := cascade.GetReceiver().Eval(scope)
+	if err != nil {
+		return nil, err
+	}
 	var result SmalltalkObjectInterface
+	for _, each := range cascade.messages {
+		result, err = each.evalWithReceiver(receiver, scope)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (block *BlockNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	return &SmalltalkBlock{&SmalltalkObject{}, block, scope, scope.GetContext(), nil}, nil
+}
+
+func (sequence *SequenceNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	var result SmalltalkObjectInterface
+	var err error
+	// temporaries are nil at the start of every activation
+	for _, each := range sequence.temporaries {
+		scope.SetVar(each.GetName(), nil)
+	}
 	for _, each := range sequence.statements {
-		result = each.Eval(scope)
+		result, err = each.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return result
+	return result, nil
 }
 
-func (assignment *AssignmentNode) Eval(scope *Scope) SmalltalkObjectInterface {
-	// create entry in our scope with assignment.variable and assignment.value
-	scope.SetVar(assignment.variable.GetName(), assignment.value.Eval(scope))
+func (ret *ReturnNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	result, err := ret.value.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	home := scope.GetContext()
+	if home == nil || home.finished {
+		return nil, &BlockCannotReturnError{result}
+	}
+	return nil, &nonLocalReturn{home, result}
+}
+
+func (assignment *AssignmentNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	value, err := assignment.value.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	scope.Assign(assignment.variable.GetName(), value)
 	// return value for assignment variable
 	return assignment.variable.Eval(scope)
 }
 
-func (variable *VariableNode) Eval(scope *Scope) SmalltalkObjectInterface {
+func (variable *VariableNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	switch variable.GetName() {
+	case `self`, `super`:
+		return selfIn(scope)
+	case `thisContext`:
+		receiver, err := selfIn(scope)
+		if err != nil {
+			return nil, err
+		}
+		return &SmalltalkContext{&SmalltalkObject{}, scope.GetContext(), receiver, variable.Token.GetStart()}, nil
+	}
 	// return value for variable
 	smalltalkValue, err := scope.GetVarValue(variable.GetName())
 	if err != nil {
-		return NewSmalltalkString(err.Error())
+		global, ok := globals[variable.GetName()]
+		if !ok {
+			return nil, &UndefinedVariableError{variable.GetName(), variable.Token.GetStart()}
+		}
+		return global, nil
 	}
 	if smalltalkValue != nil && smalltalkValue.TypeOf() == DEFERRED {
-		return smalltalkValue.Value()
+		return valueOf(smalltalkValue)
 	} else {
-		return smalltalkValue
+		return smalltalkValue, nil
+	}
+}
+
+// selfIn answers the receiver of the method which is evaluated in scope. Programs are not methods, so there
+// self is the variable which the host program sets or nil.
+func selfIn(scope *Scope) (SmalltalkObjectInterface, error) {
+	if context := scope.GetContext(); context != nil && context.methodClass != nil {
+		return context.receiver, nil
+	}
+	value, err := scope.GetVarValue(`self`)
+	if err != nil {
+		return NewSmalltalkUndefinedObject(), nil
 	}
+	if value.TypeOf() == DEFERRED {
+		return valueOf(value)
+	}
+	return value, nil
 }
 
-func (array *LiteralArrayNode) Eval(scope *Scope) SmalltalkObjectInterface {
+func (array *LiteralArrayNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	if array.isByteArray {
+		return array.evalByteArray(scope)
+	}
 	arr := new(SmalltalkArray)
 	for _, each := range array.contents {
-		value := each.Eval(scope)
+		value, err := each.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
 		arr.array = append(arr.array, value)
 	}
-	return arr
+	return arr, nil
+}
+
+func (array *DynamicArrayNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
+	elements := make([]SmalltalkObjectInterface, len(array.statements))
+	for i, each := range array.statements {
+		element, err := each.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = element
+	}
+	return NewSmalltalkArray(elements), nil
+}
+
+func (array *LiteralArrayNode) evalByteArray(scope *Scope) (SmalltalkObjectInterface, error) {
+	bytes := make([]byte, len(array.contents))
+	for i, each := range array.contents {
+		value, err := each.Eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		number, ok := value.(*SmalltalkNumber)
+		if !ok {
+			return nil, &TypeMismatchError{Expected: NUMBER_OBJ, Actual: value.TypeOf()}
+		}
+		bytes[i], err = byteOf(number)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewSmalltalkByteArray(bytes), nil
 }
 
-func (literalValue *LiteralValueNode) Eval(scope *Scope) SmalltalkObjectInterface {
+func (literalValue *LiteralValueNode) Eval(scope *Scope) (SmalltalkObjectInterface, error) {
 	switch typeOfLiteral := literalValue.GetTypeOfToken(); typeOfLiteral {
 	case scanner.NUMBER:
 		{
-			number, err := strconv.ParseFloat(literalValue.GetValue(), 64)
+			number, err := parseNumber(literalValue.exactValue())
 			if err == nil {
-				object := new(SmalltalkNumber)
-				object.SetValue(number)
-				return object
+				return number, nil
 			} else {
-				return nil
+				return nil, nil
 			}
 		}
 	case scanner.STRING:
 		{
 			object := new(SmalltalkString)
 			object.SetValue(literalValue.GetValue())
-			return object
+			return object, nil
 		}
 	case scanner.BOOLEAN:
 		{
 			object := new(SmalltalkBoolean)
 			object.SetValue(literalValue.GetValue() == "true")
-			return object
+			return object, nil
 		}
+	case scanner.NIL:
+		return NewSmalltalkUndefinedObject(), nil
+	case scanner.SYMBOL:
+		return NewSmalltalkSymbol(literalValue.GetValue()), nil
+	case scanner.CHARACTER:
+		character, _ := utf8.DecodeRuneInString(literalValue.GetValue())
+		return NewSmalltalkCharacter(character), nil
 	default:
-		return nil
+		return nil, nil
 	}
 }
@@ -0,0 +1,191 @@
+package treeNodes
+
+import (
+	"math"
+)
+
+// NumericMode tells what a division by zero or a function outside of its domain answers
+type NumericMode int
+
+const (
+	// IEEEValues answers infinities and NaN like float64 arithmetic does
+	IEEEValues NumericMode = iota
+	// RaiseNumericErrors answers ZeroDivideError and DomainError, which scripts handle as ZeroDivide and DomainError
+	RaiseNumericErrors
+	// NumericDefault answers the Default of the policy instead of infinities and NaN
+	NumericDefault
+)
+
+// NumericPolicy is the numeric policy of an evaluation. The zero value answers IEEE values.
+type NumericPolicy struct {
+	Mode    NumericMode
+	Default SmalltalkObjectInterface
+}
+
+// numericFault tells which primitive answered an infinity or NaN for a division by zero or outside of the domain
+// of a function. Other results like the overflow of 1e308 * 10 have no fault and are infinities in every mode.
+type numericFault struct {
+	selector string
+	operand  string
+	division bool
+}
+
+// exceptionClass answers the class of the exception which scripts handle for the fault
+func (f *numericFault) exceptionClass() *SmalltalkClass {
+	if f.division {
+		return ZeroDivideClass
+	}
+	return DomainErrorClass
+}
+
+// zeroDivideChecked answers a division method whose infinities and NaN for a zero divisor are faults
+func zeroDivideChecked(selector string, divide func(*SmalltalkNumber, *SmalltalkNumber) *SmalltalkNumber) Method {
+	return binaryMethod(func(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
+		result := divide(receiver, arg)
+		if arg.value == 0 && !isNonFinite(receiver) && isNonFinite(result) {
+			result.fault = &numericFault{selector, displayString(receiver), true}
+		}
+		return result
+	})
+}
+
+// domainChecked answers a function method whose NaN for a finite receiver is a fault
+func domainChecked(selector string, function func(*SmalltalkNumber) *SmalltalkNumber) Method {
+	return unaryMethod(func(receiver *SmalltalkNumber) *SmalltalkNumber {
+		result := function(receiver)
+		if !isNonFinite(receiver) && math.IsNaN(result.value) {
+			result.fault = &numericFault{selector, displayString(receiver), false}
+		}
+		return result
+	})
+}
+
+// SetNumericPolicy sets the numeric policy for evaluations in this scope and in the scopes inside of it
+func (s *Scope) SetNumericPolicy(policy NumericPolicy) *Scope {
+	s.numericPolicy = &policy
+	return s
+}
+
+// GetNumericPolicy answers the policy of the nearest scope which has one
+func (s *Scope) GetNumericPolicy() NumericPolicy {
+	for scope := s; scope != nil; scope = scope.OuterScope {
+		if scope.numericPolicy != nil {
+			return *scope.numericPolicy
+		}
+	}
+	return NumericPolicy{}
+}
+
+// applyNumericPolicy checks the result of a message for numbers with a fault, so the policy holds for divisions
+// sent by perform: and inside of other primitives like normalized. Elements of arrays are checked too, so
+// elementwise arithmetic follows the policy. Faults of a receiver or an argument which is already an infinity or
+// NaN are not raised again. With IEEE values a fault is raised when an on:do: around the message handles it,
+// so [x / 0] on: ZeroDivide do: [...] handles the division like in Pharo.
+func (message *MessageNode) applyNumericPolicy(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface,
+	result SmalltalkObjectInterface, scope *Scope) (SmalltalkObjectInterface, error) {
+	fault := faultOf(result, nil)
+	if fault == nil || hasNonFinite(receiver, nil) {
+		return result, nil
+	}
+	for _, each := range args {
+		if hasNonFinite(each, nil) {
+			return result, nil
+		}
+	}
+	policy := scope.GetNumericPolicy()
+	mode := policy.Mode
+	if mode == IEEEValues && scope.handlesException(fault.exceptionClass()) {
+		mode = RaiseNumericErrors
+	}
+	switch mode {
+	case RaiseNumericErrors:
+		if fault.division {
+			return nil, &ZeroDivideError{fault.operand, fault.selector, message.GetPosition()}
+		}
+		return nil, &DomainError{fault.operand, fault.selector, message.GetPosition()}
+	case NumericDefault:
+		defaultValue := policy.Default
+		if defaultValue == nil {
+			defaultValue = NewSmalltalkUndefinedObject()
+		}
+		return replaceFaults(result, defaultValue, nil), nil
+	}
+	return result, nil
+}
+
+func isNonFinite(number *SmalltalkNumber) bool {
+	return math.IsNaN(number.value) || math.IsInf(number.value, 0)
+}
+
+func hasNonFinite(object SmalltalkObjectInterface, visited visitedObjects) bool {
+	switch typedObject := object.(type) {
+	case *SmalltalkNumber:
+		return isNonFinite(typedObject)
+	case *SmalltalkArray:
+		visited, ok := enterCollection(visited, typedObject)
+		if !ok {
+			return false
+		}
+		defer delete(visited, typedObject)
+		for _, each := range typedObject.array {
+			if hasNonFinite(each, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// faultOf answers the fault of the first number with a fault in object or nil
+func faultOf(object SmalltalkObjectInterface, visited visitedObjects) *numericFault {
+	switch typedObject := object.(type) {
+	case *SmalltalkNumber:
+		return typedObject.fault
+	case *SmalltalkArray:
+		visited, ok := enterCollection(visited, typedObject)
+		if !ok {
+			return nil
+		}
+		defer delete(visited, typedObject)
+		for _, each := range typedObject.array {
+			if fault := faultOf(each, visited); fault != nil {
+				return fault
+			}
+		}
+	}
+	return nil
+}
+
+func replaceFaults(object SmalltalkObjectInterface, defaultValue SmalltalkObjectInterface, visited visitedObjects) SmalltalkObjectInterface {
+	switch typedObject := object.(type) {
+	case *SmalltalkNumber:
+		if typedObject.fault != nil {
+			return defaultValue
+		}
+	case *SmalltalkArray:
+		visited, ok := enterCollection(visited, typedObject)
+		if !ok {
+			return object
+		}
+		defer delete(visited, typedObject)
+		elements := make([]SmalltalkObjectInterface, len(typedObject.array))
+		for i, each := range typedObject.array {
+			elements[i] = replaceFaults(each, defaultValue, visited)
+		}
+		return NewSmalltalkArray(elements)
+	}
+	return object
+}
+
+// Number methods which guard scripts against infinities and NaN
+func isNaN(receiver *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(math.IsNaN(receiver.value))
+}
+
+func isInfinite(receiver *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(math.IsInf(receiver.value, 0))
+}
+
+func isFinite(receiver *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(!isNonFinite(receiver))
+}
@@ -0,0 +1,188 @@
+package treeNodes
+
+import (
+	"errors"
+)
+
+const BAG_OBJ = "BAG"
+
+var bagMessages = withCollectionMessages(map[string]Method{
+	`add:`:                 binaryMethod(bagAdd),
+	`add:withOccurrences:`: ternaryMethodE(bagAddWithOccurrences),
+	`addAll:`:              binaryMethod(bagAddAll),
+	`remove:`:              binaryMethodE(bagRemove),
+	`remove:ifAbsent:`:     ternaryMethodE(bagRemoveIfAbsent),
+	`occurrencesOf:`:       binaryMethod(occurrencesOf),
+	`includes:`:            binaryMethod(bagIncludes),
+	`asSet`:                unaryMethod(bagAsSet),
+})
+
+// bagClassMessages are understood by the Bag global
+var bagClassMessages = map[string]Method{
+	`new`:                  unaryMethod(newBag),
+	`new:`:                 binaryMethod(newBagWithCapacity),
+	`with:`:                variadicMethodE(bagWith),
+	`with:with:`:           variadicMethodE(bagWith),
+	`with:with:with:`:      variadicMethodE(bagWith),
+	`with:with:with:with:`: variadicMethodE(bagWith),
+	`withAll:`:             binaryMethod(bagWithAll),
+}
+
+func init() {
+	globals[`Bag`] = NewSmalltalkClass(`Bag`, bagClassMessages)
+}
+
+// Bag methods
+func bagAdd(receiver *SmalltalkBag, element SmalltalkObjectInterface) SmalltalkObjectInterface {
+	receiver.add(element, 1)
+	return element
+}
+
+func bagAddWithOccurrences(receiver *SmalltalkBag, element SmalltalkObjectInterface, occurrences *SmalltalkNumber) (SmalltalkObjectInterface, error) {
+	count, ok := occurrences.GetInt64()
+	if !occurrences.IsInteger() || !ok || count < 0 {
+		return nil, errors.New("occurrences must be a non negative integer, got " + occurrences.printString(10))
+	}
+	receiver.add(element, int(count))
+	return element, nil
+}
+
+func bagAddAll(receiver *SmalltalkBag, elements collection) SmalltalkObjectInterface {
+	for _, each := range elements.elements() {
+		receiver.add(each, 1)
+	}
+	return elements
+}
+
+func bagRemove(receiver *SmalltalkBag, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if !receiver.remove(element) {
+		return nil, errors.New(displayString(element) + " not found")
+	}
+	return element, nil
+}
+
+func bagRemoveIfAbsent(receiver *SmalltalkBag, element SmalltalkObjectInterface, absentBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if !receiver.remove(element) {
+		return valueOf(absentBlock)
+	}
+	return element, nil
+}
+
+func occurrencesOf(receiver *SmalltalkBag, element SmalltalkObjectInterface) *SmalltalkNumber {
+	i, ok := receiver.index[equalityKey(element)]
+	if !ok {
+		return NewSmalltalkInteger(0)
+	}
+	return NewSmalltalkInteger(int64(receiver.counts[i]))
+}
+
+func bagIncludes(receiver *SmalltalkBag, element SmalltalkObjectInterface) *SmalltalkBoolean {
+	_, ok := receiver.index[equalityKey(element)]
+	return NewSmalltalkBoolean(ok)
+}
+
+func bagAsSet(receiver *SmalltalkBag) *SmalltalkSet {
+	return NewSmalltalkSet(receiver.distinct)
+}
+
+// Bag class methods
+func newBag(receiver *SmalltalkClass) *SmalltalkBag {
+	return NewSmalltalkBag(nil)
+}
+
+func newBagWithCapacity(receiver *SmalltalkClass, capacity *SmalltalkNumber) *SmalltalkBag {
+	return NewSmalltalkBag(nil)
+}
+
+func bagWith(receiver *SmalltalkClass, elements []SmalltalkObjectInterface) (*SmalltalkBag, error) {
+	return NewSmalltalkBag(elements), nil
+}
+
+func bagWithAll(receiver *SmalltalkClass, elements collection) *SmalltalkBag {
+	return NewSmalltalkBag(elements.elements())
+}
+
+// SmalltalkBag is a collection which counts occurrences of equal elements. Equal elements are enumerated
+// together in the order the first of them was added.
+type SmalltalkBag struct {
+	*SmalltalkObject
+	distinct []SmalltalkObjectInterface
+	counts   []int
+	index    map[interface{}]int
+}
+
+func NewSmalltalkBag(elements []SmalltalkObjectInterface) *SmalltalkBag {
+	bag := &SmalltalkBag{&SmalltalkObject{}, nil, nil, make(map[interface{}]int)}
+	for _, each := range elements {
+		bag.add(each, 1)
+	}
+	return bag
+}
+
+func (b *SmalltalkBag) add(element SmalltalkObjectInterface, occurrences int) {
+	if occurrences == 0 {
+		return
+	}
+	key := equalityKey(element)
+	if i, ok := b.index[key]; ok {
+		b.counts[i] += occurrences
+		return
+	}
+	b.index[key] = len(b.distinct)
+	b.distinct = append(b.distinct, element)
+	b.counts = append(b.counts, occurrences)
+}
+
+// remove removes one occurrence of element
+func (b *SmalltalkBag) remove(element SmalltalkObjectInterface) bool {
+	i, ok := b.index[equalityKey(element)]
+	if !ok {
+		return false
+	}
+	b.counts[i]--
+	if b.counts[i] > 0 {
+		return true
+	}
+	b.distinct = append(b.distinct[:i], b.distinct[i+1:]...)
+	b.counts = append(b.counts[:i], b.counts[i+1:]...)
+	b.index = make(map[interface{}]int)
+	for offset, each := range b.distinct {
+		b.index[equalityKey(each)] = offset
+	}
+	return true
+}
+
+func (b *SmalltalkBag) elements() []SmalltalkObjectInterface {
+	var result []SmalltalkObjectInterface
+	for i, each := range b.distinct {
+		for occurrence := 0; occurrence < b.counts[i]; occurrence++ {
+			result = append(result, each)
+		}
+	}
+	return result
+}
+
+func (b *SmalltalkBag) species(elements []SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return NewSmalltalkBag(elements)
+}
+
+func (b *SmalltalkBag) displayString(visited visitedObjects) string {
+	return collectionDisplayString("Bag", b.elements(), visited)
+}
+
+func (b *SmalltalkBag) Value() SmalltalkObjectInterface {
+	return b
+}
+
+func (b *SmalltalkBag) TypeOf() string {
+	return BAG_OBJ
+}
+
+func (b *SmalltalkBag) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(b, bagMessages, name, params)
+}
+
+// GetValue answers the elements converted to Go values like EvaluateToInterface does, every occurrence separately
+func (b *SmalltalkBag) GetValue() ([]interface{}, error) {
+	return interfaceValues(b, b.elements(), nil)
+}
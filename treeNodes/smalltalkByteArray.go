@@ -0,0 +1,171 @@
+package treeNodes
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const BYTE_ARRAY_OBJ = "BYTE_ARRAY"
+
+var byteArrayMessages = map[string]Method{
+	`value`:       unaryMethodE(value),
+	`=`:           binaryMethod(byteArrayEqual),
+	`~=`:          binaryMethod(byteArrayNotEqual),
+	`size`:        unaryMethod(byteArraySize),
+	`isEmpty`:     unaryMethod(byteArrayIsEmpty),
+	`notEmpty`:    unaryMethod(byteArrayNotEmpty),
+	`at:`:         binaryMethodE(byteArrayAt),
+	`at:put:`:     ternaryMethodE(byteArrayAtPut),
+	`first`:       unaryMethodE(byteArrayFirst),
+	`last`:        unaryMethodE(byteArrayLast),
+	`includes:`:   binaryMethod(byteArrayIncludes),
+	`do:`:         binaryMethodE(byteArrayDo),
+	`collect:`:    binaryMethodE(byteArrayCollect),
+	`asArray`:     unaryMethod(byteArrayAsArray),
+	`asString`:    unaryMethod(byteArrayAsString),
+	`printString`: unaryMethod(byteArrayPrintString),
+}
+
+// ByteArray methods
+func byteArrayEqual(receiver *SmalltalkByteArray, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	other, ok := arg.(*SmalltalkByteArray)
+	return NewSmalltalkBoolean(ok && bytes.Equal(receiver.bytes, other.bytes))
+}
+
+func byteArrayNotEqual(receiver *SmalltalkByteArray, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return not(byteArrayEqual(receiver, arg))
+}
+
+func byteArraySize(receiver *SmalltalkByteArray) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(len(receiver.bytes)))
+}
+
+func byteArrayIsEmpty(receiver *SmalltalkByteArray) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(len(receiver.bytes) == 0)
+}
+
+func byteArrayNotEmpty(receiver *SmalltalkByteArray) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(len(receiver.bytes) != 0)
+}
+
+func byteArrayAt(receiver *SmalltalkByteArray, index *SmalltalkNumber) (*SmalltalkNumber, error) {
+	i, err := offsetOf(index, len(receiver.bytes))
+	if err != nil {
+		return nil, err
+	}
+	return NewSmalltalkInteger(int64(receiver.bytes[i])), nil
+}
+
+func byteArrayAtPut(receiver *SmalltalkByteArray, index *SmalltalkNumber, element *SmalltalkNumber) (*SmalltalkNumber, error) {
+	i, err := offsetOf(index, len(receiver.bytes))
+	if err != nil {
+		return nil, err
+	}
+	byteValue, err := byteOf(element)
+	if err != nil {
+		return nil, err
+	}
+	receiver.bytes[i] = byteValue
+	return element, nil
+}
+
+func byteArrayFirst(receiver *SmalltalkByteArray) (*SmalltalkNumber, error) {
+	return byteArrayAt(receiver, NewSmalltalkInteger(1))
+}
+
+func byteArrayLast(receiver *SmalltalkByteArray) (*SmalltalkNumber, error) {
+	return byteArrayAt(receiver, NewSmalltalkInteger(int64(len(receiver.bytes))))
+}
+
+func byteArrayIncludes(receiver *SmalltalkByteArray, element SmalltalkObjectInterface) *SmalltalkBoolean {
+	number, ok := element.(*SmalltalkNumber)
+	if !ok {
+		return NewSmalltalkBoolean(false)
+	}
+	byteValue, err := byteOf(number)
+	return NewSmalltalkBoolean(err == nil && bytes.IndexByte(receiver.bytes, byteValue) >= 0)
+}
+
+func byteArrayDo(receiver *SmalltalkByteArray, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	for _, each := range receiver.bytes {
+		_, err := block.ValueWithArguments([]SmalltalkObjectInterface{NewSmalltalkInteger(int64(each))})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receiver, nil
+}
+
+func byteArrayCollect(receiver *SmalltalkByteArray, block *SmalltalkBlock) (*SmalltalkArray, error) {
+	result := make([]SmalltalkObjectInterface, len(receiver.bytes))
+	for i, each := range receiver.bytes {
+		element, err := block.ValueWithArguments([]SmalltalkObjectInterface{NewSmalltalkInteger(int64(each))})
+		if err != nil {
+			return nil, err
+		}
+		result[i] = element
+	}
+	return NewSmalltalkArray(result), nil
+}
+
+func byteArrayAsArray(receiver *SmalltalkByteArray) *SmalltalkArray {
+	result := make([]SmalltalkObjectInterface, len(receiver.bytes))
+	for i, each := range receiver.bytes {
+		result[i] = NewSmalltalkInteger(int64(each))
+	}
+	return NewSmalltalkArray(result)
+}
+
+// byteArrayAsString answers the string with the bytes of the receiver, so #[104 105] asString is 'hi'
+func byteArrayAsString(receiver *SmalltalkByteArray) *SmalltalkString {
+	return NewSmalltalkString(string(receiver.bytes))
+}
+
+func byteArrayPrintString(receiver *SmalltalkByteArray) *SmalltalkString {
+	return NewSmalltalkString(receiver.printString())
+}
+
+// byteOf answers the value of an integer from 0 to 255
+func byteOf(number *SmalltalkNumber) (byte, error) {
+	value, ok := number.GetInt64()
+	if !number.IsInteger() || !ok || value < 0 || value > 255 {
+		return 0, errors.New(number.printString(10) + " is not a byte")
+	}
+	return byte(value), nil
+}
+
+// SmalltalkByteArray is an array of integers from 0 to 255 like #[1 2 255]
+type SmalltalkByteArray struct {
+	*SmalltalkObject
+	bytes []byte
+}
+
+func NewSmalltalkByteArray(bytes []byte) *SmalltalkByteArray {
+	return &SmalltalkByteArray{&SmalltalkObject{}, bytes}
+}
+
+func (b *SmalltalkByteArray) printString() string {
+	elements := make([]string, len(b.bytes))
+	for i, each := range b.bytes {
+		elements[i] = strconv.Itoa(int(each))
+	}
+	return "#[" + strings.Join(elements, " ") + "]"
+}
+
+func (b *SmalltalkByteArray) Value() SmalltalkObjectInterface {
+	return b
+}
+
+func (b *SmalltalkByteArray) TypeOf() string {
+	return BYTE_ARRAY_OBJ
+}
+
+func (b *SmalltalkByteArray) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(b, byteArrayMessages, name, params)
+}
+
+func (b *SmalltalkByteArray) GetValue() []byte {
+	return b.bytes
+}
@@ -0,0 +1,151 @@
+package treeNodes
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const CHARACTER_OBJ = "CHARACTER"
+
+var characterMessages = map[string]Method{
+	`value`:       unaryMethodE(value),
+	`=`:           binaryMethod(identical),
+	`~=`:          binaryMethod(notIdentical),
+	`<`:           binaryMethod(characterLesser),
+	`<=`:          binaryMethod(characterLesserEqual),
+	`>`:           binaryMethod(characterGreater),
+	`>=`:          binaryMethod(characterGreaterEqual),
+	`asInteger`:   unaryMethod(characterAsInteger),
+	`asCharacter`: unaryMethod(characterAsCharacter),
+	`isVowel`:     unaryMethod(isVowel),
+	`isDigit`:     unaryMethod(isDigit),
+	`isLetter`:    unaryMethod(isLetter),
+	`isUppercase`: unaryMethod(isUppercase),
+	`isLowercase`: unaryMethod(isLowercase),
+	`asUppercase`: unaryMethod(characterAsUppercase),
+	`asLowercase`: unaryMethod(characterAsLowercase),
+	`asString`:    unaryMethod(characterAsString),
+	`asSymbol`:    unaryMethod(characterAsSymbol),
+	`printString`: unaryMethod(characterPrintString),
+}
+
+// characterClassMessages are understood by the Character global
+var characterClassMessages = map[string]Method{
+	`value:`: binaryMethodE(characterValue),
+}
+
+func init() {
+	globals[`Character`] = NewSmalltalkClass(`Character`, characterClassMessages)
+}
+
+// Character methods
+func characterLesser(receiver *SmalltalkCharacter, arg *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value < arg.value)
+}
+
+func characterLesserEqual(receiver *SmalltalkCharacter, arg *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value <= arg.value)
+}
+
+func characterGreater(receiver *SmalltalkCharacter, arg *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value > arg.value)
+}
+
+func characterGreaterEqual(receiver *SmalltalkCharacter, arg *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value >= arg.value)
+}
+
+func characterAsInteger(receiver *SmalltalkCharacter) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(receiver.value))
+}
+
+func characterAsCharacter(receiver *SmalltalkCharacter) *SmalltalkCharacter {
+	return receiver
+}
+
+func isVowel(receiver *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(strings.ContainsRune("aeiouAEIOU", receiver.value))
+}
+
+func isDigit(receiver *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(unicode.IsDigit(receiver.value))
+}
+
+func isLetter(receiver *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(unicode.IsLetter(receiver.value))
+}
+
+func isUppercase(receiver *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(unicode.IsUpper(receiver.value))
+}
+
+func isLowercase(receiver *SmalltalkCharacter) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(unicode.IsLower(receiver.value))
+}
+
+func characterAsUppercase(receiver *SmalltalkCharacter) *SmalltalkCharacter {
+	return NewSmalltalkCharacter(unicode.ToUpper(receiver.value))
+}
+
+func characterAsLowercase(receiver *SmalltalkCharacter) *SmalltalkCharacter {
+	return NewSmalltalkCharacter(unicode.ToLower(receiver.value))
+}
+
+func characterAsString(receiver *SmalltalkCharacter) *SmalltalkString {
+	return NewSmalltalkString(string(receiver.value))
+}
+
+func characterAsSymbol(receiver *SmalltalkCharacter) *SmalltalkSymbol {
+	return NewSmalltalkSymbol(string(receiver.value))
+}
+
+func characterPrintString(receiver *SmalltalkCharacter) *SmalltalkString {
+	return NewSmalltalkString("$" + string(receiver.value))
+}
+
+// Number methods
+func numberAsCharacter(receiver *SmalltalkNumber) (*SmalltalkCharacter, error) {
+	return characterValue(nil, receiver)
+}
+
+// Character class methods
+
+// characterValue answers the character with the unicode code point, so Character value: 97 is $a
+func characterValue(receiver *SmalltalkClass, codePoint *SmalltalkNumber) (*SmalltalkCharacter, error) {
+	value, ok := codePoint.GetInt64()
+	if !ok || value < 0 || value > unicode.MaxRune || !utf8.ValidRune(rune(value)) {
+		return nil, errors.New(codePoint.printString(10) + " is not a code point of a character")
+	}
+	return NewSmalltalkCharacter(rune(value)), nil
+}
+
+// SmalltalkCharacter is a unicode character like $a. Characters with the same value are identical.
+// Character is the Go value of a Character. Go has no character type, a rune is an int32 and so an Integer.
+type Character rune
+
+type SmalltalkCharacter struct {
+	*SmalltalkObject
+	value rune
+}
+
+func NewSmalltalkCharacter(value rune) *SmalltalkCharacter {
+	return &SmalltalkCharacter{&SmalltalkObject{}, value}
+}
+
+func (c *SmalltalkCharacter) Value() SmalltalkObjectInterface {
+	return c
+}
+
+func (c *SmalltalkCharacter) TypeOf() string {
+	return CHARACTER_OBJ
+}
+
+func (c *SmalltalkCharacter) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(c, characterMessages, name, params)
+}
+
+func (c *SmalltalkCharacter) GetValue() rune {
+	return c.value
+}
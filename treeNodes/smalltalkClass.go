@@ -0,0 +1,341 @@
+package treeNodes
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const CLASS_OBJ = "CLASS"
+
+// globals are the objects which every program sees, like the Character class. Variables of a scope
+// with the same name hide them.
+var globals = map[string]SmalltalkObjectInterface{}
+
+// objectClassMessages are understood by Object and all its subclasses, so scripts define classes with them
+var objectClassMessages = map[string]Method{
+	`new`:                             unaryMethodE(newInstance),
+	`subclass:`:                       binaryMethodE(subclass),
+	`subclass:instanceVariableNames:`: ternaryMethodE(subclassWithVariables),
+	`subclass:instanceVariableNames:classVariableNames:package:`:  variadicMethodE(subclassWithClassVariables),
+	`subclass:instanceVariableNames:classVariableNames:category:`: variadicMethodE(subclassWithClassVariables),
+	`name`:           unaryMethod(className),
+	`superclass`:     unaryMethod(superclass),
+	`printString`:    unaryMethod(className),
+	`inheritsFrom:`:  binaryMethod(inheritsFrom),
+	`canUnderstand:`: binaryMethodE(canUnderstand),
+}
+
+// classDefinitionSelectors answer a new class. Sent with a scope, by a message expression or by perform:, they
+// make it a variable of the outermost scope of the evaluation, so later evaluations see it.
+var classDefinitionSelectors = []string{
+	`subclass:`,
+	`subclass:instanceVariableNames:`,
+	`subclass:instanceVariableNames:classVariableNames:package:`,
+	`subclass:instanceVariableNames:classVariableNames:category:`,
+}
+
+// ObjectClass is the root of the classes which scripts define. Exception classes inherit from it too.
+var ObjectClass = NewSmalltalkClass(`Object`, objectClassMessages)
+
+func init() {
+	globals[ObjectClass.GetName()] = ObjectClass
+	classScopedMessages = map[string]scopedMethod{}
+	for _, each := range classDefinitionSelectors {
+		classScopedMessages[each] = classDefinition(objectClassMessages[each])
+	}
+}
+
+// classDefinition answers the scoped method of a class definition, which defines the class answered by define
+// in the outermost scope
+func classDefinition(define Method) scopedMethod {
+	return func(scope *Scope, receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		result, err := define(receiver, args)
+		if err != nil {
+			return nil, err
+		}
+		return scope.defineClass(result.(*SmalltalkClass)), nil
+	}
+}
+
+// Object class methods
+func newInstance(receiver *SmalltalkClass) (SmalltalkObjectInterface, error) {
+	instance := &SmalltalkInstance{&SmalltalkObject{}, receiver, receiver.newVariables()}
+	// like in Pharo new sends initialize, but only classes defined by scripts have it
+	if receiver.methodFor(`initialize`) != nil {
+		_, err := instance.Perform(`initialize`, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+func subclass(receiver *SmalltalkClass, name *SmalltalkSymbol) (*SmalltalkClass, error) {
+	return receiver.defineSubclass(name.GetValue(), "", "")
+}
+
+func subclassWithVariables(receiver *SmalltalkClass, name *SmalltalkSymbol, instanceVariableNames *SmalltalkString) (*SmalltalkClass, error) {
+	return receiver.defineSubclass(name.GetValue(), instanceVariableNames.GetValue(), "")
+}
+
+// subclassWithClassVariables ignores the package or the category of the class
+func subclassWithClassVariables(receiver *SmalltalkClass, args []SmalltalkObjectInterface) (*SmalltalkClass, error) {
+	err := checkArgumentsCount(args, 4)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := args[0].(*SmalltalkSymbol)
+	if !ok {
+		return nil, &TypeMismatchError{Expected: SYMBOL_OBJ, Actual: args[0].TypeOf()}
+	}
+	for _, each := range args[1:] {
+		if _, ok := each.(*SmalltalkString); !ok {
+			return nil, &TypeMismatchError{Expected: STRING_OBJ, Actual: each.TypeOf()}
+		}
+	}
+	return receiver.defineSubclass(name.GetValue(), args[1].(*SmalltalkString).GetValue(), args[2].(*SmalltalkString).GetValue())
+}
+
+func className(receiver *SmalltalkClass) *SmalltalkString {
+	return NewSmalltalkString(receiver.name)
+}
+
+func superclass(receiver *SmalltalkClass) SmalltalkObjectInterface {
+	if receiver.superclass == nil {
+		return NewSmalltalkUndefinedObject()
+	}
+	return receiver.superclass
+}
+
+func inheritsFrom(receiver *SmalltalkClass, other SmalltalkObjectInterface) *SmalltalkBoolean {
+	class, ok := other.(*SmalltalkClass)
+	return NewSmalltalkBoolean(ok && receiver != class && receiver.IncludesBehavior(class))
+}
+
+// canUnderstand answers whether instances of the class have a method for selector. Only methods of classes
+// defined by scripts are known.
+func canUnderstand(receiver *SmalltalkClass, selector SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	name, err := selectorName(selector)
+	if err != nil {
+		return nil, err
+	}
+	return NewSmalltalkBoolean(receiver.understands(name)), nil
+}
+
+// SmalltalkClass is a global which answers class side messages like Character value: 97.
+// Every class has its own messages, so classes have no shared message table and handle messages in Perform.
+// A class understands the messages of its superclass too.
+//
+// Classes defined by scripts have instance variables, class variables and methods which are evaluated like
+// programs. Their class side methods are compiled into messages.
+type SmalltalkClass struct {
+	*SmalltalkObject
+	name                  string
+	messages              map[string]Method
+	superclass            *SmalltalkClass
+	instanceVariableNames []string
+	classVariables        map[string]SmalltalkObjectInterface
+	methods               map[string]*MethodNode
+	environment           *Scope
+}
+
+func NewSmalltalkClass(name string, messages map[string]Method) *SmalltalkClass {
+	return &SmalltalkClass{SmalltalkObject: &SmalltalkObject{}, name: name, messages: messages}
+}
+
+// NewSmalltalkSubclass answers a class which inherits messages from superclass
+func NewSmalltalkSubclass(name string, superclass *SmalltalkClass, messages map[string]Method) *SmalltalkClass {
+	return &SmalltalkClass{SmalltalkObject: &SmalltalkObject{}, name: name, messages: messages, superclass: superclass}
+}
+
+// defineSubclass answers a new subclass. Names of variables are separated by spaces like in Pharo.
+func (c *SmalltalkClass) defineSubclass(name string, instanceVariableNames string, classVariableNames string) (*SmalltalkClass, error) {
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		return nil, errors.New(`class name "` + name + `" should start with an uppercase letter`)
+	}
+	class := NewSmalltalkSubclass(name, c, map[string]Method{})
+	class.methods = map[string]*MethodNode{}
+	class.classVariables = map[string]SmalltalkObjectInterface{}
+	for _, each := range strings.Fields(instanceVariableNames) {
+		if includesName(c.allInstanceVariableNames(), each) || includesName(class.instanceVariableNames, each) {
+			return nil, errors.New(`instance variable "` + each + `" is already defined for ` + name)
+		}
+		class.instanceVariableNames = append(class.instanceVariableNames, each)
+	}
+	for _, each := range strings.Fields(classVariableNames) {
+		class.classVariables[each] = NewSmalltalkUndefinedObject()
+	}
+	return class, nil
+}
+
+// redefine takes variables of class which is defined again with the same name. Methods and values of class
+// variables remain, instances created before keep their instance variables.
+func (c *SmalltalkClass) redefine(class *SmalltalkClass) {
+	c.superclass = class.superclass
+	c.instanceVariableNames = class.instanceVariableNames
+	classVariables := class.classVariables
+	for name := range classVariables {
+		if value, ok := c.classVariables[name]; ok {
+			classVariables[name] = value
+		}
+	}
+	c.classVariables = classVariables
+}
+
+func includesName(names []string, name string) bool {
+	for _, each := range names {
+		if each == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *SmalltalkClass) allInstanceVariableNames() []string {
+	var names []string
+	if c.superclass != nil {
+		names = c.superclass.allInstanceVariableNames()
+	}
+	return append(names, c.instanceVariableNames...)
+}
+
+// newVariables answers instance variables of a new instance, they are all nil
+func (c *SmalltalkClass) newVariables() map[string]SmalltalkObjectInterface {
+	names := c.allInstanceVariableNames()
+	if len(names) == 0 {
+		return nil
+	}
+	variables := make(map[string]SmalltalkObjectInterface, len(names))
+	for _, each := range names {
+		variables[each] = NewSmalltalkUndefinedObject()
+	}
+	return variables
+}
+
+// AddMethod adds method to the methods of instances of the class or replaces the method with the same selector.
+// Built-in classes are shared by all evaluators, so only classes defined by scripts get methods.
+func (c *SmalltalkClass) AddMethod(method *MethodNode) error {
+	if c.methods == nil {
+		return c.builtInClassError()
+	}
+	methodTablesLock.Lock()
+	defer methodTablesLock.Unlock()
+	c.methods[method.GetSelector()] = method
+	return nil
+}
+
+// AddClassMethod adds method to the class side of the class, so the class itself understands it
+func (c *SmalltalkClass) AddClassMethod(method *MethodNode) error {
+	if c.methods == nil {
+		return c.builtInClassError()
+	}
+	methodTablesLock.Lock()
+	defer methodTablesLock.Unlock()
+	c.messages[method.GetSelector()] = func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		return activateMethod(method, c, receiver, nil, args)
+	}
+	return nil
+}
+
+func (c *SmalltalkClass) builtInClassError() error {
+	return errors.New(c.name + " is a built-in class, its methods can not be changed")
+}
+
+func (c *SmalltalkClass) understands(selector string) bool {
+	return c.methodFor(selector) != nil || lookupMethod(instanceMessages, selector) != nil
+}
+
+// methodFor answers the method for selector which instances of the class have or nil
+func (c *SmalltalkClass) methodFor(selector string) *MethodNode {
+	method, _ := c.methodAndClassFor(selector)
+	return method
+}
+
+// methodAndClassFor answers the method for selector which instances of the class have and the class of the
+// method, or nils. Methods are added while other goroutines send messages, so lookups hold methodTablesLock.
+func (c *SmalltalkClass) methodAndClassFor(selector string) (*MethodNode, *SmalltalkClass) {
+	methodTablesLock.RLock()
+	defer methodTablesLock.RUnlock()
+	for class := c; class != nil; class = class.superclass {
+		if method, ok := class.methods[selector]; ok {
+			return method, class
+		}
+	}
+	return nil, nil
+}
+
+// variablesScope answers the scope of class variables of the class and its superclasses. It is inside of
+// the scope the class was defined in.
+func (c *SmalltalkClass) variablesScope(context *Context) *Scope {
+	var classes []*SmalltalkClass
+	for class := c; class != nil; class = class.superclass {
+		classes = append(classes, class)
+	}
+	scope := c.environment
+	for i := len(classes) - 1; i >= 0; i-- {
+		if len(classes[i].classVariables) > 0 {
+			scope = &Scope{variables: classes[i].classVariables, OuterScope: scope, context: context}
+		}
+	}
+	return scope
+}
+
+func (c *SmalltalkClass) Value() SmalltalkObjectInterface {
+	return c
+}
+
+func (c *SmalltalkClass) TypeOf() string {
+	return CLASS_OBJ
+}
+
+func (c *SmalltalkClass) respondsTo(selector string) bool {
+	return c.messageOwner(selector) != nil || lookupMethod(c.messages, selector) != nil
+}
+
+func (c *SmalltalkClass) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return c.performFrom(c, name, params)
+}
+
+// messageOwner answers the class whose class side messages have a method for selector or nil
+func (c *SmalltalkClass) messageOwner(selector string) *SmalltalkClass {
+	methodTablesLock.RLock()
+	defer methodTablesLock.RUnlock()
+	for class := c; class != nil; class = class.superclass {
+		if _, ok := class.messages[selector]; ok {
+			return class
+		}
+	}
+	return nil
+}
+
+// performFrom looks up the class side method for name in start and its superclasses, so super sends skip the
+// methods of the receiver class
+func (c *SmalltalkClass) performFrom(start *SmalltalkClass, name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	var table map[string]Method
+	if start != nil {
+		table = start.messages
+	}
+	if owner := start.messageOwner(name); owner != nil {
+		table = owner.messages
+	}
+	return performMethod(c, table, name, params)
+}
+
+func (c *SmalltalkClass) GetName() string {
+	return c.name
+}
+
+func (c *SmalltalkClass) GetSuperclass() *SmalltalkClass {
+	return c.superclass
+}
+
+// IncludesBehavior answers whether the class is other or inherits from it
+func (c *SmalltalkClass) IncludesBehavior(other *SmalltalkClass) bool {
+	for class := c; class != nil; class = class.superclass {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
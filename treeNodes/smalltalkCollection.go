@@ -0,0 +1,472 @@
+package treeNodes
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// collection is implemented by objects which understand the shared enumeration protocol
+type collection interface {
+	SmalltalkObjectInterface
+	// elements answers the elements in the order of enumeration. Changing the answer does not change the collection.
+	elements() []SmalltalkObjectInterface
+	// species answers a new collection of the kind which select: and collect: answer
+	species(elements []SmalltalkObjectInterface) SmalltalkObjectInterface
+}
+
+// collectionMessages are understood by every collection unless its own message table overrides them
+var collectionMessages = map[string]Method{
+	`value`:               unaryMethodE(value),
+	`size`:                unaryMethod(collectionSize),
+	`isEmpty`:             unaryMethod(collectionIsEmpty),
+	`notEmpty`:            unaryMethod(collectionNotEmpty),
+	`includes:`:           binaryMethod(collectionIncludes),
+	`do:`:                 binaryMethodE(collectionDo),
+	`collect:`:            binaryMethodE(collectionCollect),
+	`select:`:             binaryMethodE(collectionSelect),
+	`reject:`:             binaryMethodE(collectionReject),
+	`detect:`:             binaryMethodE(collectionDetect),
+	`detect:ifNone:`:      ternaryMethodE(collectionDetectIfNone),
+	`inject:into:`:        ternaryMethodE(collectionInjectInto),
+	`anySatisfy:`:         binaryMethodE(collectionAnySatisfy),
+	`allSatisfy:`:         binaryMethodE(collectionAllSatisfy),
+	`asArray`:             unaryMethod(collectionAsArray),
+	`asOrderedCollection`: unaryMethod(collectionAsOrderedCollection),
+	`asSortedCollection`:  unaryMethodE(collectionAsSortedCollection),
+	`asSortedCollection:`: binaryMethodE(collectionAsSortedCollectionWith),
+	`asSet`:               unaryMethod(collectionAsSet),
+	`asBag`:               unaryMethod(collectionAsBag),
+	`max`:                 unaryMethodE(collectionMax),
+	`min`:                 unaryMethodE(collectionMin),
+	`sum`:                 unaryMethodE(collectionSum),
+	`average`:             unaryMethodE(collectionAverage),
+	`printString`:         unaryMethod(collectionPrintString),
+}
+
+// sequenceableMessages are understood by collections with ordered elements which have indices
+var sequenceableMessages = map[string]Method{
+	`at:ifAbsent:`:     ternaryMethodE(sequenceableAtIfAbsent),
+	`keysAndValuesDo:`: binaryMethodE(sequenceableKeysAndValuesDo),
+	`indexOf:`:         binaryMethod(sequenceableIndexOf),
+}
+
+// withCollectionMessages answers the message table of a collection type. Messages of the type override the shared ones.
+func withCollectionMessages(messages map[string]Method, shared ...map[string]Method) map[string]Method {
+	result := make(map[string]Method)
+	for _, each := range append([]map[string]Method{collectionMessages}, shared...) {
+		for selector, method := range each {
+			result[selector] = method
+		}
+	}
+	for selector, method := range messages {
+		result[selector] = method
+	}
+	return result
+}
+
+// Collection methods
+func collectionSize(receiver collection) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(len(receiver.elements())))
+}
+
+func collectionIsEmpty(receiver collection) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(len(receiver.elements()) == 0)
+}
+
+func collectionNotEmpty(receiver collection) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(len(receiver.elements()) != 0)
+}
+
+func collectionIncludes(receiver collection, element SmalltalkObjectInterface) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(indexOfElement(receiver.elements(), element) >= 0)
+}
+
+func collectionDo(receiver collection, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	for _, each := range receiver.elements() {
+		_, err := block.ValueWithArguments([]SmalltalkObjectInterface{each})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receiver, nil
+}
+
+func collectionCollect(receiver collection, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	elements := receiver.elements()
+	result := make([]SmalltalkObjectInterface, len(elements))
+	for i, each := range elements {
+		element, err := block.ValueWithArguments([]SmalltalkObjectInterface{each})
+		if err != nil {
+			return nil, err
+		}
+		result[i] = element
+	}
+	return receiver.species(result), nil
+}
+
+func collectionSelect(receiver collection, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	result, err := selectElements(receiver.elements(), block, true)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.species(result), nil
+}
+
+func collectionReject(receiver collection, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	result, err := selectElements(receiver.elements(), block, false)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.species(result), nil
+}
+
+// collectionDetect answers the first element for which block is true or nil if there is no such element
+func collectionDetect(receiver collection, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	return collectionDetectIfNone(receiver, block, NewSmalltalkUndefinedObject())
+}
+
+func collectionDetectIfNone(receiver collection, block *SmalltalkBlock, noneBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	for _, each := range receiver.elements() {
+		found, err := testElement(block, each)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return each, nil
+		}
+	}
+	return valueOf(noneBlock)
+}
+
+func collectionInjectInto(receiver collection, initial SmalltalkObjectInterface, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	accumulator := initial
+	for _, each := range receiver.elements() {
+		next, err := block.ValueWithArguments([]SmalltalkObjectInterface{accumulator, each})
+		if err != nil {
+			return nil, err
+		}
+		accumulator = next
+	}
+	return accumulator, nil
+}
+
+func collectionAnySatisfy(receiver collection, block *SmalltalkBlock) (*SmalltalkBoolean, error) {
+	for _, each := range receiver.elements() {
+		satisfied, err := testElement(block, each)
+		if err != nil {
+			return nil, err
+		}
+		if satisfied {
+			return NewSmalltalkBoolean(true), nil
+		}
+	}
+	return NewSmalltalkBoolean(false), nil
+}
+
+func collectionAllSatisfy(receiver collection, block *SmalltalkBlock) (*SmalltalkBoolean, error) {
+	for _, each := range receiver.elements() {
+		satisfied, err := testElement(block, each)
+		if err != nil {
+			return nil, err
+		}
+		if !satisfied {
+			return NewSmalltalkBoolean(false), nil
+		}
+	}
+	return NewSmalltalkBoolean(true), nil
+}
+
+func collectionAsArray(receiver collection) *SmalltalkArray {
+	return NewSmalltalkArray(receiver.elements())
+}
+
+func collectionAsOrderedCollection(receiver collection) *SmalltalkOrderedCollection {
+	return NewSmalltalkOrderedCollection(receiver.elements())
+}
+
+func collectionAsSortedCollection(receiver collection) (*SmalltalkOrderedCollection, error) {
+	return NewSmalltalkSortedCollection(receiver.elements(), nil)
+}
+
+func collectionAsSortedCollectionWith(receiver collection, sortBlock *SmalltalkBlock) (*SmalltalkOrderedCollection, error) {
+	return NewSmalltalkSortedCollection(receiver.elements(), sortBlock)
+}
+
+func collectionAsSet(receiver collection) *SmalltalkSet {
+	return NewSmalltalkSet(receiver.elements())
+}
+
+func collectionAsBag(receiver collection) *SmalltalkBag {
+	return NewSmalltalkBag(receiver.elements())
+}
+
+// collectionMax answers the greatest element. Elements are compared with >.
+func collectionMax(receiver collection) (SmalltalkObjectInterface, error) {
+	return extremeElement(receiver.elements(), `>`)
+}
+
+// collectionMin answers the least element. Elements are compared with <.
+func collectionMin(receiver collection) (SmalltalkObjectInterface, error) {
+	return extremeElement(receiver.elements(), `<`)
+}
+
+// collectionSum adds the elements with +. The results of + and / are checked by the message which sends sum or
+// average, so they follow the numeric policy like the results of other primitives do.
+func collectionSum(receiver collection) (SmalltalkObjectInterface, error) {
+	elements := receiver.elements()
+	if len(elements) == 0 {
+		return nil, &EmptyCollectionError{}
+	}
+	sum := elements[0]
+	for _, each := range elements[1:] {
+		next, err := Send(sum, `+`, []SmalltalkObjectInterface{each})
+		if err != nil {
+			return nil, err
+		}
+		sum = next
+	}
+	return sum, nil
+}
+
+func collectionAverage(receiver collection) (SmalltalkObjectInterface, error) {
+	sum, err := collectionSum(receiver)
+	if err != nil {
+		return nil, err
+	}
+	return Send(sum, `/`, []SmalltalkObjectInterface{NewSmalltalkInteger(int64(len(receiver.elements())))})
+}
+
+func collectionPrintString(receiver collection) *SmalltalkString {
+	return NewSmalltalkString(displayString(receiver))
+}
+
+// Sequenceable collection methods
+func sequenceableAtIfAbsent(receiver collection, index *SmalltalkNumber, absentBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	elements := receiver.elements()
+	i, err := offsetOf(index, len(elements))
+	if err != nil {
+		return valueOf(absentBlock)
+	}
+	return elements[i], nil
+}
+
+// sequenceableKeysAndValuesDo evaluates block with the index and the element for every element
+func sequenceableKeysAndValuesDo(receiver collection, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	for i, each := range receiver.elements() {
+		_, err := block.ValueWithArguments([]SmalltalkObjectInterface{NewSmalltalkInteger(int64(i + 1)), each})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receiver, nil
+}
+
+// sequenceableIndexOf answers the index of the first element equal to element or 0 if there is no such element
+func sequenceableIndexOf(receiver collection, element SmalltalkObjectInterface) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(indexOfElement(receiver.elements(), element) + 1))
+}
+
+// extremeElement answers the element for which comparison with every other element is true
+func extremeElement(elements []SmalltalkObjectInterface, comparison string) (SmalltalkObjectInterface, error) {
+	if len(elements) == 0 {
+		return nil, &EmptyCollectionError{}
+	}
+	extreme := elements[0]
+	for _, each := range elements[1:] {
+		result, err := Send(each, comparison, []SmalltalkObjectInterface{extreme})
+		if err != nil {
+			return nil, err
+		}
+		boolean, ok := result.(*SmalltalkBoolean)
+		if !ok {
+			return nil, &TypeMismatchError{Expected: BOOLEAN_OBJ, Actual: result.TypeOf()}
+		}
+		if boolean.GetValue() {
+			extreme = each
+		}
+	}
+	return extreme, nil
+}
+
+// testElement answers the result of block for element, which must be a boolean
+func testElement(block *SmalltalkBlock, element SmalltalkObjectInterface) (bool, error) {
+	result, err := block.ValueWithArguments([]SmalltalkObjectInterface{element})
+	if err != nil {
+		return false, err
+	}
+	boolean, ok := result.(*SmalltalkBoolean)
+	if !ok {
+		return false, &TypeMismatchError{Expected: BOOLEAN_OBJ, Actual: result.TypeOf()}
+	}
+	return boolean.GetValue(), nil
+}
+
+// selectElements answers elements for which block answers expected
+func selectElements(elements []SmalltalkObjectInterface, block *SmalltalkBlock, expected bool) ([]SmalltalkObjectInterface, error) {
+	result := []SmalltalkObjectInterface{}
+	for _, each := range elements {
+		satisfied, err := testElement(block, each)
+		if err != nil {
+			return nil, err
+		}
+		if satisfied == expected {
+			result = append(result, each)
+		}
+	}
+	return result, nil
+}
+
+// sortElements sorts elements in place so that sortBlock answers true for every pair of neighbours.
+// Without sortBlock elements are sorted with <=. Sorting is stable.
+func sortElements(elements []SmalltalkObjectInterface, sortBlock *SmalltalkBlock) error {
+	var sortError error
+	sort.SliceStable(elements, func(i, j int) bool {
+		ordered, err := inOrder(elements[j], elements[i], sortBlock)
+		if err != nil && sortError == nil {
+			sortError = err
+		}
+		return !ordered
+	})
+	return sortError
+}
+
+// insertionIndex answers the offset after the last element which may be before element, so inserting there keeps
+// sorted elements sorted. It compares log2 n elements.
+func insertionIndex(elements []SmalltalkObjectInterface, element SmalltalkObjectInterface, sortBlock *SmalltalkBlock) (int, error) {
+	var searchError error
+	index := sort.Search(len(elements), func(i int) bool {
+		ordered, err := inOrder(elements[i], element, sortBlock)
+		if err != nil && searchError == nil {
+			searchError = err
+		}
+		return !ordered
+	})
+	return index, searchError
+}
+
+// inOrder answers whether sortBlock, or <= without sortBlock, answers true for a and b. A failed comparison answers true
+// with the error.
+func inOrder(a SmalltalkObjectInterface, b SmalltalkObjectInterface, sortBlock *SmalltalkBlock) (bool, error) {
+	var result SmalltalkObjectInterface
+	var err error
+	if sortBlock == nil {
+		result, err = Send(a, `<=`, []SmalltalkObjectInterface{b})
+	} else {
+		result, err = sortBlock.ValueWithArguments([]SmalltalkObjectInterface{a, b})
+	}
+	if err != nil {
+		return true, err
+	}
+	boolean, ok := result.(*SmalltalkBoolean)
+	if !ok {
+		return true, &TypeMismatchError{Expected: BOOLEAN_OBJ, Actual: result.TypeOf()}
+	}
+	return boolean.GetValue(), nil
+}
+
+// visitedObjects are the collections which are being enumerated. A collection may contain itself after at:put: or
+// add:, so functions which enumerate elements of elements keep the visited collections to stop there.
+type visitedObjects map[SmalltalkObjectInterface]bool
+
+// enterCollection adds collection to visited and answers visited, or false when collection is already visited.
+// The caller removes collection from visited after enumerating it.
+func enterCollection(visited visitedObjects, collection SmalltalkObjectInterface) (visitedObjects, bool) {
+	if visited[collection] {
+		return visited, false
+	}
+	if visited == nil {
+		visited = visitedObjects{}
+	}
+	visited[collection] = true
+	return visited, true
+}
+
+// Equality of elements
+
+type numberKey string
+type stringKey string
+type characterKey rune
+type bytesKey string
+type arrayKey string
+type undefinedKey struct{}
+
+// equalityKey answers a Go value which is the same for objects equal in Smalltalk, so it can be a key of a Go map.
+// Numbers, strings, characters, booleans, nil and byte arrays are equal when their values are, arrays when their
+// elements are and other objects only to themselves.
+func equalityKey(object SmalltalkObjectInterface) interface{} {
+	return elementKey(object, nil)
+}
+
+// elementKey answers the equality key of object. Arrays which contain themselves answer a key for the arrays in
+// visited instead of their elements.
+func elementKey(object SmalltalkObjectInterface, visited visitedObjects) interface{} {
+	switch typedObject := object.(type) {
+	case *SmalltalkArray:
+		visited, ok := enterCollection(visited, typedObject)
+		if !ok {
+			return arrayKey("cycle")
+		}
+		defer delete(visited, typedObject)
+		var key strings.Builder
+		for _, each := range typedObject.array {
+			// the type of every key is kept, so 1 and '1' are different elements
+			eachKey := elementKey(each, visited)
+			fmt.Fprintf(&key, "%T(%#v),", eachKey, eachKey)
+		}
+		return arrayKey(key.String())
+	case *SmalltalkNumber:
+		if value, ok := rationalOf(typedObject); ok {
+			return numberKey(value.RatString())
+		}
+		return numberKey(strconv.FormatFloat(typedObject.value, 'g', -1, 64))
+	case *SmalltalkString:
+		return stringKey(typedObject.value)
+	case *SmalltalkCharacter:
+		return characterKey(typedObject.value)
+	case *SmalltalkBoolean:
+		return typedObject.value
+	case *SmalltalkUndefinedObject:
+		return undefinedKey{}
+	case *SmalltalkByteArray:
+		return bytesKey(typedObject.bytes)
+	}
+	return object
+}
+
+func objectsEqual(a SmalltalkObjectInterface, b SmalltalkObjectInterface) bool {
+	return equalityKey(a) == equalityKey(b)
+}
+
+// indexOfElement answers the offset of the first element equal to element or -1 if there is no such element
+func indexOfElement(elements []SmalltalkObjectInterface, element SmalltalkObjectInterface) int {
+	key := equalityKey(element)
+	for i, each := range elements {
+		if equalityKey(each) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// collectionDisplayString answers the text of a collection like an OrderedCollection(1 2 3)
+func collectionDisplayString(name string, elements []SmalltalkObjectInterface, visited visitedObjects) string {
+	texts := make([]string, len(elements))
+	for i, each := range elements {
+		texts[i] = displayStringIn(each, visited)
+	}
+	return collectionText(name, texts)
+}
+
+func collectionText(name string, texts []string) string {
+	return withArticle(name) + "(" + strings.Join(texts, " ") + ")"
+}
+
+// withArticle answers the name of a class with an article, like an OrderedCollection
+func withArticle(name string) string {
+	if strings.ContainsAny(name[:1], "AEIOU") {
+		return "an " + name
+	}
+	return "a " + name
+}
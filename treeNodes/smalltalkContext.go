@@ -0,0 +1,68 @@
+package treeNodes
+
+const CONTEXT_OBJ = "CONTEXT"
+
+// contextMessages describe the activation which evaluates thisContext, so scripts can report where they are
+var contextMessages = map[string]Method{
+	`selector`:    unaryMethod(contextSelector),
+	`receiver`:    unaryMethod(contextReceiver),
+	`methodClass`: unaryMethod(contextMethodClass),
+	`position`:    unaryMethod(contextPosition),
+	`printString`: unaryMethod(contextPrintString),
+}
+
+// Context methods
+func contextSelector(receiver *SmalltalkContext) SmalltalkObjectInterface {
+	if receiver.context == nil || receiver.context.methodClass == nil {
+		return NewSmalltalkUndefinedObject()
+	}
+	return NewSmalltalkSymbol(receiver.context.selector)
+}
+
+func contextReceiver(receiver *SmalltalkContext) SmalltalkObjectInterface {
+	return receiver.receiver
+}
+
+func contextMethodClass(receiver *SmalltalkContext) SmalltalkObjectInterface {
+	if receiver.context == nil || receiver.context.methodClass == nil {
+		return NewSmalltalkUndefinedObject()
+	}
+	return receiver.context.methodClass
+}
+
+// contextPosition answers the source position of thisContext in the method or the program
+func contextPosition(receiver *SmalltalkContext) *SmalltalkNumber {
+	return NewSmalltalkInteger(receiver.position)
+}
+
+// contextPrintString answers Gauge>>span for methods like Pharo does and DoIt for programs
+func contextPrintString(receiver *SmalltalkContext) *SmalltalkString {
+	return NewSmalltalkString(receiver.displayString())
+}
+
+// SmalltalkContext is the value of thisContext. It is read-only: scripts cannot change the activation through it.
+type SmalltalkContext struct {
+	*SmalltalkObject
+	context  *Context
+	receiver SmalltalkObjectInterface
+	position int64
+}
+
+func (c *SmalltalkContext) displayString() string {
+	if c.context == nil || c.context.methodClass == nil {
+		return `DoIt`
+	}
+	return c.context.methodClass.GetName() + `>>` + c.context.selector
+}
+
+func (c *SmalltalkContext) Value() SmalltalkObjectInterface {
+	return c
+}
+
+func (c *SmalltalkContext) TypeOf() string {
+	return CONTEXT_OBJ
+}
+
+func (c *SmalltalkContext) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(c, contextMessages, name, params)
+}
@@ -0,0 +1,142 @@
+package treeNodes
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+)
+
+// InterfaceValue answers object converted to a Go value. Numbers are converted like GetInterfaceValue does, characters are Characters,
+// arrays and other collections are []interface{}, byte arrays are []byte, dictionaries are map[string]interface{}
+// and Go objects answer their Go values.
+func InterfaceValue(object SmalltalkObjectInterface) (interface{}, error) {
+	return interfaceValue(object, nil)
+}
+
+// errCyclicCollection is answered for a collection which contains itself, because its Go value would be infinite
+var errCyclicCollection = errors.New(`we do not support collections which contain themselves in Go values`)
+
+// interfaceValue answers the Go value of object which is an element of the collections in visited
+func interfaceValue(object SmalltalkObjectInterface, visited visitedObjects) (interface{}, error) {
+	switch typedObject := object.(type) {
+	case *SmalltalkNumber:
+		return typedObject.GetInterfaceValue(), nil
+	case *SmalltalkString:
+		return typedObject.GetValue(), nil
+	case *SmalltalkSymbol:
+		return typedObject.GetValue(), nil
+	case *SmalltalkCharacter:
+		return Character(typedObject.GetValue()), nil
+	case *SmalltalkBoolean:
+		return typedObject.GetValue(), nil
+	case *SmalltalkUndefinedObject:
+		return nil, nil
+	case *SmalltalkInterval:
+		return typedObject.GetValue(), nil
+	case *SmalltalkByteArray:
+		return typedObject.GetValue(), nil
+	case *SmalltalkArray:
+		return interfaceValues(typedObject, typedObject.array, visited)
+	case *SmalltalkOrderedCollection:
+		return interfaceValues(typedObject, typedObject.array, visited)
+	case *SmalltalkSet:
+		return interfaceValues(typedObject, typedObject.array, visited)
+	case *SmalltalkBag:
+		return interfaceValues(typedObject, typedObject.elements(), visited)
+	case *SmalltalkDictionary:
+		return typedObject.goMap(visited)
+	case *SmalltalkGoObject:
+		return typedObject.GetValue(), nil
+	default:
+		return nil, errors.New(`we do not support this type "` + object.TypeOf() + `" in Go values`)
+	}
+}
+
+// interfaceValues answers the Go values of elements of collection, which is an element of the collections in visited
+func interfaceValues(collection SmalltalkObjectInterface, elements []SmalltalkObjectInterface, visited visitedObjects) ([]interface{}, error) {
+	visited, ok := enterCollection(visited, collection)
+	if !ok {
+		return nil, errCyclicCollection
+	}
+	defer delete(visited, collection)
+	values := make([]interface{}, len(elements))
+	for i, each := range elements {
+		value, err := interfaceValue(each, visited)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// NewSmalltalkObjectFrom answers a smalltalk object for a Go value. Integers and runes become Integers, floats become Floats,
+// Character becomes a Character, *big.Rat becomes a Fraction, []byte becomes a ByteArray, other slices become Arrays and maps with string keys become Dictionaries with String keys.
+// Structs and pointers to structs become Go objects (see NewSmalltalkGoObject), nil pointers become nil.
+// Smalltalk objects are answered as they are.
+func NewSmalltalkObjectFrom(value interface{}) (SmalltalkObjectInterface, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return NewSmalltalkUndefinedObject(), nil
+	case SmalltalkObjectInterface:
+		return typedValue, nil
+	case bool:
+		return NewSmalltalkBoolean(typedValue), nil
+	case string:
+		return NewSmalltalkString(typedValue), nil
+	case Character:
+		return NewSmalltalkCharacter(rune(typedValue)), nil
+	case []byte:
+		return NewSmalltalkByteArray(append([]byte{}, typedValue...)), nil
+	case *big.Int:
+		return NewSmalltalkLargeInteger(new(big.Int).Set(typedValue)), nil
+	case *big.Rat:
+		return NewSmalltalkFraction(new(big.Rat).Set(typedValue)), nil
+	}
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewSmalltalkInteger(reflectValue.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewSmalltalkLargeInteger(new(big.Int).SetUint64(reflectValue.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return NewSmalltalkNumber(reflectValue.Float()), nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]SmalltalkObjectInterface, reflectValue.Len())
+		for i := range elements {
+			element, err := NewSmalltalkObjectFrom(reflectValue.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return NewSmalltalkArray(elements), nil
+	case reflect.Map:
+		if reflectValue.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("we do not support maps with %v keys", reflectValue.Type().Key())
+		}
+		keys := reflectValue.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		dictionary := NewSmalltalkDictionary()
+		for _, key := range keys {
+			element, err := NewSmalltalkObjectFrom(reflectValue.MapIndex(key).Interface())
+			if err != nil {
+				return nil, err
+			}
+			dictionary.atPut(NewSmalltalkString(key.String()), element)
+		}
+		return dictionary, nil
+	case reflect.Ptr:
+		if reflectValue.IsNil() {
+			return NewSmalltalkUndefinedObject(), nil
+		}
+		if reflectValue.Elem().Kind() == reflect.Struct {
+			return NewSmalltalkGoObject(value)
+		}
+	case reflect.Struct:
+		return NewSmalltalkGoObject(value)
+	}
+	return nil, fmt.Errorf("we do not support Go values of type %T", value)
+}
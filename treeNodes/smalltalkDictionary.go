@@ -0,0 +1,296 @@
+package treeNodes
+
+import (
+	"errors"
+)
+
+const DICTIONARY_OBJ = "DICTIONARY"
+
+// dictionaryMessages enumerate values of a dictionary, so do:, select: and includes: see only values like in Pharo
+var dictionaryMessages = withCollectionMessages(map[string]Method{
+	`at:`:                 binaryMethodE(dictionaryAt),
+	`at:put:`:             ternaryMethod(dictionaryAtPut),
+	`at:ifAbsent:`:        ternaryMethodE(dictionaryAtIfAbsent),
+	`at:ifAbsentPut:`:     ternaryMethodE(dictionaryAtIfAbsentPut),
+	`removeKey:`:          binaryMethodE(removeKey),
+	`removeKey:ifAbsent:`: ternaryMethodE(removeKeyIfAbsent),
+	`includesKey:`:        binaryMethod(includesKey),
+	`keyAtValue:`:         binaryMethod(keyAtValue),
+	`keys`:                unaryMethod(dictionaryKeys),
+	`values`:              unaryMethod(dictionaryValues),
+	`keysDo:`:             binaryMethodE(keysDo),
+	`keysAndValuesDo:`:    binaryMethodE(dictionaryKeysAndValuesDo),
+	`collect:`:            binaryMethodE(dictionaryCollect),
+	`select:`:             binaryMethodE(dictionarySelect),
+	`reject:`:             binaryMethodE(dictionaryReject),
+})
+
+// dictionaryClassMessages are understood by the Dictionary global
+var dictionaryClassMessages = map[string]Method{
+	`new`:  unaryMethod(newDictionary),
+	`new:`: binaryMethod(newDictionaryWithCapacity),
+}
+
+func init() {
+	globals[`Dictionary`] = NewSmalltalkClass(`Dictionary`, dictionaryClassMessages)
+}
+
+// Dictionary methods
+func dictionaryAt(receiver *SmalltalkDictionary, key SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	value, ok := receiver.at(key)
+	if !ok {
+		return nil, errors.New("key not found: " + displayString(key))
+	}
+	return value, nil
+}
+
+func dictionaryAtPut(receiver *SmalltalkDictionary, key SmalltalkObjectInterface, value SmalltalkObjectInterface) SmalltalkObjectInterface {
+	receiver.atPut(key, value)
+	return value
+}
+
+func dictionaryAtIfAbsent(receiver *SmalltalkDictionary, key SmalltalkObjectInterface, absentBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	value, ok := receiver.at(key)
+	if !ok {
+		return valueOf(absentBlock)
+	}
+	return value, nil
+}
+
+func dictionaryAtIfAbsentPut(receiver *SmalltalkDictionary, key SmalltalkObjectInterface, absentBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	value, ok := receiver.at(key)
+	if ok {
+		return value, nil
+	}
+	value, err := valueOf(absentBlock)
+	if err != nil {
+		return nil, err
+	}
+	receiver.atPut(key, value)
+	return value, nil
+}
+
+func removeKey(receiver *SmalltalkDictionary, key SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	value, ok := receiver.removeKey(key)
+	if !ok {
+		return nil, errors.New("key not found: " + displayString(key))
+	}
+	return value, nil
+}
+
+func removeKeyIfAbsent(receiver *SmalltalkDictionary, key SmalltalkObjectInterface, absentBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	value, ok := receiver.removeKey(key)
+	if !ok {
+		return valueOf(absentBlock)
+	}
+	return value, nil
+}
+
+func includesKey(receiver *SmalltalkDictionary, key SmalltalkObjectInterface) *SmalltalkBoolean {
+	_, ok := receiver.index[equalityKey(key)]
+	return NewSmalltalkBoolean(ok)
+}
+
+// keyAtValue answers the first key with value or nil if there is no such key
+func keyAtValue(receiver *SmalltalkDictionary, value SmalltalkObjectInterface) SmalltalkObjectInterface {
+	i := indexOfElement(receiver.values, value)
+	if i < 0 {
+		return NewSmalltalkUndefinedObject()
+	}
+	return receiver.keys[i]
+}
+
+func dictionaryKeys(receiver *SmalltalkDictionary) *SmalltalkArray {
+	return NewSmalltalkArray(append([]SmalltalkObjectInterface{}, receiver.keys...))
+}
+
+func dictionaryValues(receiver *SmalltalkDictionary) *SmalltalkArray {
+	return NewSmalltalkArray(receiver.elements())
+}
+
+func keysDo(receiver *SmalltalkDictionary, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	for _, key := range append([]SmalltalkObjectInterface{}, receiver.keys...) {
+		_, err := block.ValueWithArguments([]SmalltalkObjectInterface{key})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receiver, nil
+}
+
+func dictionaryKeysAndValuesDo(receiver *SmalltalkDictionary, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	keys := append([]SmalltalkObjectInterface{}, receiver.keys...)
+	values := receiver.elements()
+	for i, key := range keys {
+		_, err := block.ValueWithArguments([]SmalltalkObjectInterface{key, values[i]})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receiver, nil
+}
+
+// dictionaryCollect answers a dictionary with the same keys and the results of block for their values
+func dictionaryCollect(receiver *SmalltalkDictionary, block *SmalltalkBlock) (*SmalltalkDictionary, error) {
+	result := NewSmalltalkDictionary()
+	for i, value := range receiver.elements() {
+		collected, err := block.ValueWithArguments([]SmalltalkObjectInterface{value})
+		if err != nil {
+			return nil, err
+		}
+		result.atPut(receiver.keys[i], collected)
+	}
+	return result, nil
+}
+
+func dictionarySelect(receiver *SmalltalkDictionary, block *SmalltalkBlock) (*SmalltalkDictionary, error) {
+	return receiver.selectValues(block, true)
+}
+
+func dictionaryReject(receiver *SmalltalkDictionary, block *SmalltalkBlock) (*SmalltalkDictionary, error) {
+	return receiver.selectValues(block, false)
+}
+
+// Dictionary class methods
+func newDictionary(receiver *SmalltalkClass) *SmalltalkDictionary {
+	return NewSmalltalkDictionary()
+}
+
+func newDictionaryWithCapacity(receiver *SmalltalkClass, capacity *SmalltalkNumber) *SmalltalkDictionary {
+	return NewSmalltalkDictionary()
+}
+
+// SmalltalkDictionary maps keys to values. Keys are equal when they are equal in Smalltalk, so 1 and 1.0 are the same key.
+// Keys are enumerated in the order they were added.
+type SmalltalkDictionary struct {
+	*SmalltalkObject
+	keys   []SmalltalkObjectInterface
+	values []SmalltalkObjectInterface
+	index  map[interface{}]int
+}
+
+func NewSmalltalkDictionary() *SmalltalkDictionary {
+	return &SmalltalkDictionary{&SmalltalkObject{}, nil, nil, make(map[interface{}]int)}
+}
+
+func (d *SmalltalkDictionary) at(key SmalltalkObjectInterface) (SmalltalkObjectInterface, bool) {
+	i, ok := d.index[equalityKey(key)]
+	if !ok {
+		return nil, false
+	}
+	return d.values[i], true
+}
+
+func (d *SmalltalkDictionary) atPut(key SmalltalkObjectInterface, value SmalltalkObjectInterface) {
+	equality := equalityKey(key)
+	if i, ok := d.index[equality]; ok {
+		d.values[i] = value
+		return
+	}
+	d.index[equality] = len(d.keys)
+	d.keys = append(d.keys, key)
+	d.values = append(d.values, value)
+}
+
+func (d *SmalltalkDictionary) removeKey(key SmalltalkObjectInterface) (SmalltalkObjectInterface, bool) {
+	i, ok := d.index[equalityKey(key)]
+	if !ok {
+		return nil, false
+	}
+	value := d.values[i]
+	d.keys = append(d.keys[:i], d.keys[i+1:]...)
+	d.values = append(d.values[:i], d.values[i+1:]...)
+	d.index = make(map[interface{}]int)
+	for offset, each := range d.keys {
+		d.index[equalityKey(each)] = offset
+	}
+	return value, true
+}
+
+func (d *SmalltalkDictionary) selectValues(block *SmalltalkBlock, expected bool) (*SmalltalkDictionary, error) {
+	result := NewSmalltalkDictionary()
+	for i, value := range d.elements() {
+		satisfied, err := testElement(block, value)
+		if err != nil {
+			return nil, err
+		}
+		if satisfied == expected {
+			result.atPut(d.keys[i], value)
+		}
+	}
+	return result, nil
+}
+
+// elements answers the values of the dictionary
+func (d *SmalltalkDictionary) elements() []SmalltalkObjectInterface {
+	return append([]SmalltalkObjectInterface{}, d.values...)
+}
+
+func (d *SmalltalkDictionary) species(elements []SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return NewSmalltalkBag(elements)
+}
+
+func (d *SmalltalkDictionary) displayString(visited visitedObjects) string {
+	pairs := make([]string, len(d.keys))
+	for i, key := range d.keys {
+		pairs[i] = displayStringIn(key, visited) + "->" + displayStringIn(d.values[i], visited)
+	}
+	return collectionText("Dictionary", pairs)
+}
+
+// Keys answers the keys of the dictionary in the order they were added
+func (d *SmalltalkDictionary) Keys() []SmalltalkObjectInterface {
+	return append([]SmalltalkObjectInterface{}, d.keys...)
+}
+
+// At answers the value at key and whether the dictionary has the key
+func (d *SmalltalkDictionary) At(key SmalltalkObjectInterface) (SmalltalkObjectInterface, bool) {
+	return d.at(key)
+}
+
+// AtPut sets the value at key
+func (d *SmalltalkDictionary) AtPut(key SmalltalkObjectInterface, value SmalltalkObjectInterface) {
+	d.atPut(key, value)
+}
+
+func (d *SmalltalkDictionary) Value() SmalltalkObjectInterface {
+	return d
+}
+
+func (d *SmalltalkDictionary) TypeOf() string {
+	return DICTIONARY_OBJ
+}
+
+func (d *SmalltalkDictionary) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(d, dictionaryMessages, name, params)
+}
+
+// GetValue answers the dictionary as a Go map. Keys are converted to their text, so #name and 'name' are the key "name".
+// Different keys with the same text, like 1 and '1' in one dictionary, are an error.
+func (d *SmalltalkDictionary) GetValue() (map[string]interface{}, error) {
+	return d.goMap(nil)
+}
+
+// goMap answers the dictionary as a Go map like GetValue does. The dictionary is an element of the collections in visited.
+func (d *SmalltalkDictionary) goMap(visited visitedObjects) (map[string]interface{}, error) {
+	visited, ok := enterCollection(visited, d)
+	if !ok {
+		return nil, errCyclicCollection
+	}
+	defer delete(visited, d)
+	result := make(map[string]interface{}, len(d.keys))
+	goKeys := make(map[string]SmalltalkObjectInterface, len(d.keys))
+	for i, key := range d.keys {
+		value, err := interfaceValue(d.values[i], visited)
+		if err != nil {
+			return nil, err
+		}
+		goKey := displayString(key)
+		if other, ok := goKeys[goKey]; ok {
+			return nil, errors.New(`the ` + other.TypeOf() + ` key and the ` + key.TypeOf() + ` key of the dictionary are both "` + goKey + `" in Go`)
+		}
+		goKeys[goKey] = key
+		result[goKey] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,235 @@
+package treeNodes
+
+import (
+	"fmt"
+	"strings"
+)
+
+type UndefinedVariableError struct {
+	Name     string
+	Position int64
+}
+
+func (e *UndefinedVariableError) Error() string {
+	return fmt.Sprintf(`undefined variable "%s" at %d`, e.Name, e.Position)
+}
+
+type DoesNotUnderstandError struct {
+	Selector     string
+	ReceiverType string
+	Position     int64
+}
+
+func (e *DoesNotUnderstandError) Error() string {
+	return fmt.Sprintf(`%s doesNotUnderstand: #%s at %d`, e.ReceiverType, e.Selector, e.Position)
+}
+
+func (e *DoesNotUnderstandError) setSend(selector string, receiverType string, position int64) {
+	if e.Selector == "" {
+		e.Selector = selector
+		e.ReceiverType = receiverType
+	}
+	if e.Position == 0 {
+		e.Position = position
+	}
+}
+
+// NonBooleanReceiverError is answered when a message like ifTrue: or and: is sent to an object which is not a boolean
+type NonBooleanReceiverError struct {
+	Selector     string
+	ReceiverType string
+	Position     int64
+}
+
+func (e *NonBooleanReceiverError) Error() string {
+	return fmt.Sprintf(`NonBooleanReceiver: #%s sent to %s which is not a boolean at %d`, e.Selector, e.ReceiverType, e.Position)
+}
+
+func (e *NonBooleanReceiverError) setSend(selector string, receiverType string, position int64) {
+	if e.Position == 0 {
+		e.Position = position
+	}
+}
+
+// MustBeBooleanError is answered when an argument of a boolean message or the result of its block is not a boolean
+type MustBeBooleanError struct {
+	Actual   string
+	Selector string
+	Position int64
+}
+
+func (e *MustBeBooleanError) Error() string {
+	return fmt.Sprintf(`MustBeBoolean: #%s expects a boolean but got %s at %d`, e.Selector, e.Actual, e.Position)
+}
+
+func (e *MustBeBooleanError) setSend(selector string, receiverType string, position int64) {
+	if e.Selector == "" {
+		e.Selector = selector
+	}
+	if e.Position == 0 {
+		e.Position = position
+	}
+}
+
+type TypeMismatchError struct {
+	Expected     string
+	Actual       string
+	Selector     string
+	ReceiverType string
+	Position     int64
+}
+
+func (e *TypeMismatchError) Error() string {
+	if e.Selector == "" {
+		return fmt.Sprintf(`type mismatch: expected %s but got %s`, e.Expected, e.Actual)
+	}
+	return fmt.Sprintf(`type mismatch: #%s sent to %s expects %s but got %s at %d`, e.Selector, e.ReceiverType, e.Expected, e.Actual, e.Position)
+}
+
+func (e *TypeMismatchError) setSend(selector string, receiverType string, position int64) {
+	if e.Selector == "" {
+		e.Selector = selector
+		e.ReceiverType = receiverType
+	}
+	if e.Position == 0 {
+		e.Position = position
+	}
+}
+
+type WrongArgumentCountError struct {
+	Selector string
+	Expected int
+	Actual   int
+	Position int64
+}
+
+func (e *WrongArgumentCountError) Error() string {
+	return fmt.Sprintf(`wrong argument count: #%s expects %d arguments but got %d at %d`, e.Selector, e.Expected, e.Actual, e.Position)
+}
+
+func (e *WrongArgumentCountError) setSend(selector string, receiverType string, position int64) {
+	if e.Selector == "" {
+		e.Selector = selector
+	}
+	if e.Position == 0 {
+		e.Position = position
+	}
+}
+
+// SubscriptOutOfBoundsError is answered when an index is not an integer from 1 to Size.
+// Subscript is the text of an index which is not an integer, then Index is 0.
+type SubscriptOutOfBoundsError struct {
+	Index     int64
+	Size      int
+	Subscript string
+}
+
+func (e *SubscriptOutOfBoundsError) Error() string {
+	if e.Subscript != "" {
+		return fmt.Sprintf(`SubscriptOutOfBounds: index %s is not an integer from 1 to %d`, e.Subscript, e.Size)
+	}
+	return fmt.Sprintf(`SubscriptOutOfBounds: index %d is out of bounds 1 to %d`, e.Index, e.Size)
+}
+
+// EmptyCollectionError is answered when a message like max, sum or removeFirst needs an element of an empty collection
+type EmptyCollectionError struct {
+	Selector       string
+	CollectionType string
+	Position       int64
+}
+
+func (e *EmptyCollectionError) Error() string {
+	return fmt.Sprintf(`CollectionIsEmpty: #%s needs an element of the empty %s at %d`, e.Selector, e.CollectionType, e.Position)
+}
+
+func (e *EmptyCollectionError) setSend(selector string, receiverType string, position int64) {
+	if e.Selector == "" {
+		e.Selector = selector
+		e.CollectionType = receiverType
+	}
+	if e.Position == 0 {
+		e.Position = position
+	}
+}
+
+// ZeroDivideError is answered when a number is divided by zero and the numeric policy raises errors
+type ZeroDivideError struct {
+	Dividend string
+	Selector string
+	Position int64
+}
+
+func (e *ZeroDivideError) Error() string {
+	return fmt.Sprintf(`ZeroDivide: #%s divides %s by zero at %d`, e.Selector, e.Dividend, e.Position)
+}
+
+// DomainError is answered when a function like sqrt is evaluated outside of its domain and the numeric policy raises errors
+type DomainError struct {
+	Argument string
+	Selector string
+	Position int64
+}
+
+func (e *DomainError) Error() string {
+	return fmt.Sprintf(`DomainError: #%s is not defined for %s at %d`, e.Selector, e.Argument, e.Position)
+}
+
+// NotAnIntegerError is answered when an integer is expected but the number is a float
+type NotAnIntegerError struct {
+	Number string
+}
+
+func (e *NotAnIntegerError) Error() string {
+	return fmt.Sprintf(`%s is not an integer`, e.Number)
+}
+
+// BlockCannotReturnError is answered when a block evaluates ^ after its home context has already returned.
+type BlockCannotReturnError struct {
+	Value SmalltalkObjectInterface
+}
+
+func (e *BlockCannotReturnError) Error() string {
+	return "BlockCannotReturn: home context of the block has already returned"
+}
+
+// JoinedError is answered when a block fails and then its ensure: or ifCurtailed: block fails too.
+// Errors keeps both errors, the error of the block first.
+type JoinedError struct {
+	Errors []error
+}
+
+func (e *JoinedError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap answers the joined errors, so errors.Is and errors.As find them
+func (e *JoinedError) Unwrap() []error {
+	return e.Errors
+}
+
+// GoPanicError is answered when a method of a Go object panics. Scripts handle it as Error.
+// It keeps the recovered value and the stack of the panic.
+type GoPanicError struct {
+	Selector  string
+	Recovered interface{}
+	Stack     []byte
+}
+
+func (e *GoPanicError) Error() string {
+	return fmt.Sprintf(`Go method for #%s panicked: %v`, e.Selector, e.Recovered)
+}
+
+// InternalError is answered when evaluation panics, which is a bug of the interpreter or of a registered primitive.
+// It keeps the recovered value and the stack of the panic.
+type InternalError struct {
+	Recovered interface{}
+	Stack     []byte
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf(`internal error: %v`, e.Recovered)
+}
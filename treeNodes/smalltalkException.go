@@ -0,0 +1,398 @@
+package treeNodes
+
+import (
+	"errors"
+)
+
+const EXCEPTION_OBJ = "EXCEPTION"
+
+var exceptionMessages = map[string]Method{
+	`messageText`: unaryMethod(messageText),
+	`description`: unaryMethod(exceptionDescription),
+	`class`:       unaryMethod(exceptionClassOf),
+	`signal`:      unaryMethodE(signal),
+	`signal:`:     binaryMethodE(signalWithText),
+	`return`:      unaryMethodE(exceptionReturn),
+	`return:`:     binaryMethodE(exceptionReturnValue),
+	`retry`:       unaryMethodE(retry),
+	`pass`:        unaryMethodE(pass),
+	`resume`:      unaryMethodE(resume),
+	`resume:`:     binaryMethodE(resumeValue),
+	`printString`: unaryMethod(exceptionPrintString),
+}
+
+// exceptionClassMessages are understood by Exception and all its subclasses
+var exceptionClassMessages = map[string]Method{
+	`new`:     unaryMethod(newException),
+	`signal`:  unaryMethodE(classSignal),
+	`signal:`: binaryMethodE(classSignalWithText),
+}
+
+// Exception classes which scripts can handle with on:do: and extend with subclasses. Errors of primitives are signalled as instances of
+// MessageNotUnderstood, SubscriptOutOfBounds, CollectionIsEmpty, ZeroDivide, DomainError or Error.
+var (
+	ExceptionClass            = NewSmalltalkSubclass(`Exception`, ObjectClass, exceptionClassMessages)
+	ErrorClass                = NewSmalltalkSubclass(`Error`, ExceptionClass, nil)
+	ArithmeticErrorClass      = NewSmalltalkSubclass(`ArithmeticError`, ErrorClass, nil)
+	ZeroDivideClass           = NewSmalltalkSubclass(`ZeroDivide`, ArithmeticErrorClass, nil)
+	DomainErrorClass          = NewSmalltalkSubclass(`DomainError`, ArithmeticErrorClass, nil)
+	MessageNotUnderstoodClass = NewSmalltalkSubclass(`MessageNotUnderstood`, ErrorClass, nil)
+	SubscriptOutOfBoundsClass = NewSmalltalkSubclass(`SubscriptOutOfBounds`, ErrorClass, nil)
+	CollectionIsEmptyClass    = NewSmalltalkSubclass(`CollectionIsEmpty`, ErrorClass, nil)
+	WarningClass              = NewSmalltalkSubclass(`Warning`, ExceptionClass, nil)
+)
+
+func init() {
+	for _, each := range []*SmalltalkClass{ExceptionClass, ErrorClass, ArithmeticErrorClass, ZeroDivideClass,
+		DomainErrorClass, MessageNotUnderstoodClass, SubscriptOutOfBoundsClass, CollectionIsEmptyClass, WarningClass} {
+		globals[each.GetName()] = each
+	}
+}
+
+// Exception methods
+func messageText(receiver *SmalltalkException) *SmalltalkString {
+	if receiver.messageText == "" {
+		return exceptionDescription(receiver)
+	}
+	return NewSmalltalkString(receiver.messageText)
+}
+
+func exceptionDescription(receiver *SmalltalkException) *SmalltalkString {
+	return NewSmalltalkString(receiver.class.GetName())
+}
+
+func exceptionClassOf(receiver *SmalltalkException) *SmalltalkClass {
+	return receiver.class
+}
+
+// signal answers the exception as an error. The message expression which sends signal runs the handler of the nearest
+// on:do: which handles the exception, see Scope.signal.
+func signal(receiver *SmalltalkException) (SmalltalkObjectInterface, error) {
+	receiver.signalled = false
+	return nil, receiver
+}
+
+func signalWithText(receiver *SmalltalkException, text *SmalltalkString) (SmalltalkObjectInterface, error) {
+	receiver.messageText = text.GetValue()
+	return signal(receiver)
+}
+
+func exceptionReturn(receiver *SmalltalkException) (SmalltalkObjectInterface, error) {
+	return exceptionReturnValue(receiver, NewSmalltalkUndefinedObject())
+}
+
+// exceptionReturnValue makes the on:do: which handles the exception answer value
+func exceptionReturnValue(receiver *SmalltalkException, value SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if receiver.handler == nil {
+		return nil, errors.New("return: should be sent to an exception inside of its handler block")
+	}
+	return nil, &handlerReturn{receiver.handler, value}
+}
+
+// retry evaluates the protected block of the on:do: which handles the exception again
+func retry(receiver *SmalltalkException) (SmalltalkObjectInterface, error) {
+	if receiver.handler == nil {
+		return nil, errors.New("retry should be sent to an exception inside of its handler block")
+	}
+	return nil, &handlerRetry{receiver.handler}
+}
+
+// pass runs the handler of an on:do: outside of the current one. When that handler resumes the exception, or there
+// is no such handler and the exception is a Warning, the message which signalled the exception answers the value.
+func pass(receiver *SmalltalkException) (SmalltalkObjectInterface, error) {
+	if receiver.handler == nil {
+		return nil, errors.New("pass should be sent to an exception inside of its handler block")
+	}
+	current := receiver.handler
+	value, err := receiver.signalFrom(current.outer)
+	if err != nil {
+		return nil, err
+	}
+	return nil, &handlerResume{current, value}
+}
+
+func resume(receiver *SmalltalkException) (SmalltalkObjectInterface, error) {
+	return resumeValue(receiver, NewSmalltalkUndefinedObject())
+}
+
+// resumeValue makes the message which signalled the exception answer value, so the protected block goes on.
+// Like in Pharo errors can not be resumed.
+func resumeValue(receiver *SmalltalkException, value SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if receiver.handler == nil {
+		return nil, errors.New("resume: should be sent to an exception inside of its handler block")
+	}
+	if receiver.class.IncludesBehavior(ErrorClass) {
+		return nil, errors.New(receiver.class.GetName() + " is not resumable")
+	}
+	return nil, &handlerResume{receiver.handler, value}
+}
+
+func exceptionPrintString(receiver *SmalltalkException) *SmalltalkString {
+	return NewSmalltalkString(displayString(receiver))
+}
+
+// Exception class methods
+func newException(receiver *SmalltalkClass) *SmalltalkException {
+	return NewSmalltalkException(receiver, "")
+}
+
+func classSignal(receiver *SmalltalkClass) (SmalltalkObjectInterface, error) {
+	return nil, NewSmalltalkException(receiver, "")
+}
+
+func classSignalWithText(receiver *SmalltalkClass, text *SmalltalkString) (SmalltalkObjectInterface, error) {
+	return nil, NewSmalltalkException(receiver, text.GetValue())
+}
+
+// Block methods which handle exceptions
+
+// onDo evaluates the protected block with a handler for exceptions of exceptionClass. Like in Pharo the handler block
+// runs where the exception is signalled, before ensure: blocks inside of the protected block, and the on:do:
+// answers its value unless it sends retry or resume:.
+func onDo(receiver *SmalltalkBlock, exceptionClass *SmalltalkClass, handlerBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if !exceptionClass.IncludesBehavior(ExceptionClass) {
+		return nil, errors.New(exceptionClass.GetName() + " is not an exception class")
+	}
+	outer := receiver.activeHandlers()
+	for {
+		frame := &handlerFrame{exceptionClass: exceptionClass, handlerBlock: handlerBlock, outer: outer}
+		result, err := receiver.withHandlers(frame).ValueWithArguments(nil)
+		frame.finished = true
+		if err == nil {
+			return result, nil
+		}
+		// an exception which was signalled where this on:do: could not be found, like inside of a block which was
+		// created outside of the protected block and evaluated by a primitive, is handled after unwinding, and
+		// resume: can only answer the value of the on:do:
+		if exception, ok := exceptionFrom(err); ok && !exception.offered[frame] && frame.handles(exception) {
+			result, err = frame.handle(exception)
+			if err == nil {
+				return result, nil
+			}
+		}
+		if ret, ok := err.(*handlerReturn); ok && ret.frame == frame {
+			return ret.value, nil
+		}
+		if resumed, ok := err.(*handlerResume); ok && resumed.frame == frame {
+			return resumed.value, nil
+		}
+		if again, ok := err.(*handlerRetry); ok && again.frame == frame {
+			continue
+		}
+		return nil, err
+	}
+}
+
+// signal runs the handler of the innermost on:do: around the scope which handles the exception signalled by err.
+// It answers the value which resumes the exception or the error which unwinds the evaluation: the return of the
+// handler to its on:do: or the exception when no on:do: handles it. Exceptions are signalled once by the message
+// expression which fails with them, outer message expressions only pass them on.
+func (s *Scope) signal(err error) (SmalltalkObjectInterface, error) {
+	exception, ok := exceptionFrom(err)
+	if !ok || exception.signalled {
+		return nil, err
+	}
+	exception.signalled = true
+	return exception.signalFrom(s.activeHandlers())
+}
+
+// activeHandlers answers the innermost on:do: whose protected block is evaluated around the scope or nil
+func (s *Scope) activeHandlers() *handlerFrame {
+	for scope := s; scope != nil; scope = scope.OuterScope {
+		if scope.handlers != nil {
+			return scope.handlers
+		}
+	}
+	return nil
+}
+
+// handlesException answers whether an on:do: around the scope handles exceptions of class
+func (s *Scope) handlesException(class *SmalltalkClass) bool {
+	for frame := s.activeHandlers(); frame != nil; frame = frame.outer {
+		if frame.active() && class.IncludesBehavior(frame.exceptionClass) {
+			return true
+		}
+	}
+	return false
+}
+
+// signalFrom runs the handler of the first of handlers and the on:do: messages outside of it which handles the
+// exception. Like in Pharo a Warning which no on:do: handles is resumed with nil.
+func (e *SmalltalkException) signalFrom(handlers *handlerFrame) (SmalltalkObjectInterface, error) {
+	for frame := handlers; frame != nil; frame = frame.outer {
+		if frame.active() && frame.handles(e) {
+			return frame.handle(e)
+		}
+	}
+	if e.class.IncludesBehavior(WarningClass) {
+		return NewSmalltalkUndefinedObject(), nil
+	}
+	return nil, e
+}
+
+// unhandledError answers the error of the primitive which an exception was created for, so an error which no on:do:
+// handles stays the error of the primitive
+func unhandledError(err error) error {
+	if exception, ok := err.(*SmalltalkException); ok && exception.cause != nil {
+		return exception.cause
+	}
+	return err
+}
+
+// exceptionFrom answers the exception signalled by err. Errors of primitives become instances of the matching
+// exception class, which keep the error. Returns with ^ and from handler blocks are not exceptions.
+func exceptionFrom(err error) (*SmalltalkException, bool) {
+	switch typedErr := err.(type) {
+	case *SmalltalkException:
+		return typedErr, true
+	case *nonLocalReturn, *handlerReturn, *handlerRetry, *handlerResume:
+		return nil, false
+	case *DoesNotUnderstandError:
+		return wrapError(MessageNotUnderstoodClass, err), true
+	case *SubscriptOutOfBoundsError:
+		return wrapError(SubscriptOutOfBoundsClass, err), true
+	case *EmptyCollectionError:
+		return wrapError(CollectionIsEmptyClass, err), true
+	case *ZeroDivideError:
+		return wrapError(ZeroDivideClass, err), true
+	case *DomainError:
+		return wrapError(DomainErrorClass, err), true
+	}
+	return wrapError(ErrorClass, err), true
+}
+
+func wrapError(class *SmalltalkClass, err error) *SmalltalkException {
+	exception := NewSmalltalkException(class, err.Error())
+	exception.cause = err
+	return exception
+}
+
+// handlerFrame is an on:do: whose protected block is evaluated. The scope of the protected block keeps it, and blocks
+// evaluated by value messages inside of the protected block get it from the scope of the message, so an exception
+// finds the handlers around the place where it is signalled. return:, retry and resume: unwind to their frame.
+type handlerFrame struct {
+	exceptionClass *SmalltalkClass
+	handlerBlock   SmalltalkObjectInterface
+	outer          *handlerFrame
+	// running is set while the handler block runs, finished after the protected block
+	running  bool
+	finished bool
+}
+
+func (f *handlerFrame) active() bool {
+	return !f.running && !f.finished
+}
+
+func (f *handlerFrame) handles(exception *SmalltalkException) bool {
+	return exception.class.IncludesBehavior(f.exceptionClass)
+}
+
+// handle runs the handler block for exception. It answers the value of resume: or the return of the handler.
+func (f *handlerFrame) handle(exception *SmalltalkException) (SmalltalkObjectInterface, error) {
+	if exception.offered == nil {
+		exception.offered = make(map[*handlerFrame]bool)
+	}
+	exception.offered[f] = true
+	current := exception.handler
+	exception.handler = f
+	f.running = true
+	result, err := cullValue(f.handlerBlock, exception)
+	f.running = false
+	exception.handler = current
+	if err == nil {
+		return nil, &handlerReturn{f, result}
+	}
+	if resumed, ok := err.(*handlerResume); ok && resumed.frame == f {
+		return resumed.value, nil
+	}
+	return nil, err
+}
+
+type handlerReturn struct {
+	frame *handlerFrame
+	value SmalltalkObjectInterface
+}
+
+func (r *handlerReturn) Error() string {
+	return "return: outside of the handler block"
+}
+
+type handlerRetry struct {
+	frame *handlerFrame
+}
+
+func (r *handlerRetry) Error() string {
+	return "retry outside of the handler block"
+}
+
+type handlerResume struct {
+	frame *handlerFrame
+	value SmalltalkObjectInterface
+}
+
+func (r *handlerResume) Error() string {
+	return "resume: outside of the handler block"
+}
+
+// SmalltalkException is an instance of an exception class. It is an error too: a signalled exception unwinds
+// the evaluation like any other error, and an exception which no on:do: handles is the error of the evaluation.
+type SmalltalkException struct {
+	*SmalltalkObject
+	class       *SmalltalkClass
+	messageText string
+	cause       error
+	handler     *handlerFrame
+	variables   map[string]SmalltalkObjectInterface
+	// signalled is set when the exception was offered to the handlers around the place where it was signalled,
+	// offered tells which of them
+	signalled bool
+	offered   map[*handlerFrame]bool
+}
+
+func NewSmalltalkException(class *SmalltalkClass, messageText string) *SmalltalkException {
+	return &SmalltalkException{SmalltalkObject: &SmalltalkObject{}, class: class, messageText: messageText, variables: class.newVariables()}
+}
+
+func (e *SmalltalkException) GetClass() *SmalltalkClass {
+	return e.class
+}
+
+func (e *SmalltalkException) GetMessageText() string {
+	return messageText(e).GetValue()
+}
+
+func (e *SmalltalkException) Error() string {
+	if e.cause != nil && e.messageText == e.cause.Error() {
+		return e.messageText
+	}
+	if e.messageText == "" {
+		return e.class.GetName()
+	}
+	return e.class.GetName() + ": " + e.messageText
+}
+
+// Unwrap answers the error of a primitive which the exception was created for
+func (e *SmalltalkException) Unwrap() error {
+	return e.cause
+}
+
+func (e *SmalltalkException) Value() SmalltalkObjectInterface {
+	return e
+}
+
+func (e *SmalltalkException) TypeOf() string {
+	return EXCEPTION_OBJ
+}
+
+func (e *SmalltalkException) respondsTo(selector string) bool {
+	return e.class.methodFor(selector) != nil || lookupMethod(exceptionMessages, selector) != nil
+}
+
+func (e *SmalltalkException) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return e.performFrom(e.class, name, params)
+}
+
+// performFrom evaluates methods which subclasses of exception classes define before the built-in ones
+func (e *SmalltalkException) performFrom(start *SmalltalkClass, name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performInstanceMethod(e, start, e.variables, exceptionMessages, name, params)
+}
@@ -0,0 +1,188 @@
+package treeNodes
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// NewSmalltalkFraction answers a Fraction or an Integer if value has no fraction part
+func NewSmalltalkFraction(value *big.Rat) *SmalltalkNumber {
+	if value.IsInt() {
+		return NewSmalltalkLargeInteger(new(big.Int).Set(value.Num()))
+	}
+	approximation, _ := value.Float64()
+	return &SmalltalkNumber{SmalltalkObject: &SmalltalkObject{}, value: approximation, kind: fraction, rational: value}
+}
+
+// NewSmalltalkScaledDecimal answers a ScaledDecimal. Its value is exact, scale is the number of digits it is printed with.
+func NewSmalltalkScaledDecimal(value *big.Rat, scale int) *SmalltalkNumber {
+	approximation, _ := value.Float64()
+	return &SmalltalkNumber{SmalltalkObject: &SmalltalkObject{}, value: approximation, kind: scaledDecimal, rational: value, scale: scale}
+}
+
+// parseScaledDecimal answers a ScaledDecimal from its literal parts like "3.14" and "2"
+func parseScaledDecimal(digits string, scale string) (*SmalltalkNumber, error) {
+	value, ok := new(big.Rat).SetString(digits)
+	if !ok {
+		return nil, errors.New("wrong scaled decimal " + digits + "s" + scale)
+	}
+	scaleValue, err := strconv.Atoi(scale)
+	if err != nil {
+		return nil, err
+	}
+	return NewSmalltalkScaledDecimal(value, scaleValue), nil
+}
+
+// exactNumber answers value as a ScaledDecimal if receiver or arg is a ScaledDecimal and as a Fraction otherwise
+func exactNumber(value *big.Rat, receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
+	if receiver.kind != scaledDecimal && arg.kind != scaledDecimal {
+		return NewSmalltalkFraction(value)
+	}
+	scale := receiver.scale
+	if arg.kind == scaledDecimal && arg.scale > scale {
+		scale = arg.scale
+	}
+	return NewSmalltalkScaledDecimal(value, scale)
+}
+
+func addRat(a *big.Rat, b *big.Rat) *big.Rat {
+	return new(big.Rat).Add(a, b)
+}
+
+func subRat(a *big.Rat, b *big.Rat) *big.Rat {
+	return new(big.Rat).Sub(a, b)
+}
+
+func mulRat(a *big.Rat, b *big.Rat) *big.Rat {
+	return new(big.Rat).Mul(a, b)
+}
+
+func quoRat(a *big.Rat, b *big.Rat) *big.Rat {
+	if b.Sign() == 0 {
+		return nil
+	}
+	return new(big.Rat).Quo(a, b)
+}
+
+func floorDivRat(a *big.Rat, b *big.Rat) *big.Rat {
+	quotient := quoRat(a, b)
+	if quotient == nil {
+		return nil
+	}
+	return new(big.Rat).SetInt(floorOfRat(quotient))
+}
+
+func floorModRat(a *big.Rat, b *big.Rat) *big.Rat {
+	quotient := floorDivRat(a, b)
+	if quotient == nil {
+		return nil
+	}
+	return quotient.Sub(a, quotient.Mul(quotient, b))
+}
+
+func remRat(a *big.Rat, b *big.Rat) *big.Rat {
+	quotient := quoRat(a, b)
+	if quotient == nil {
+		return nil
+	}
+	quotient.SetInt(truncatedOfRat(quotient))
+	return quotient.Sub(a, quotient.Mul(quotient, b))
+}
+
+func floorOfRat(value *big.Rat) *big.Int {
+	return floorDivBig(value.Num(), value.Denom())
+}
+
+func truncatedOfRat(value *big.Rat) *big.Int {
+	return new(big.Int).Quo(value.Num(), value.Denom())
+}
+
+// roundedOfRat rounds halves away from zero like Pharo
+func roundedOfRat(value *big.Rat) *big.Int {
+	half := big.NewRat(int64(value.Sign()), 2)
+	return truncatedOfRat(half.Add(value, half))
+}
+
+// rationalOf answers the exact value of a number. A float is taken as the decimal number it is printed as,
+// so 0.1 is 1/10. Infinity and NaN have no rational value.
+func rationalOf(number *SmalltalkNumber) (*big.Rat, bool) {
+	if number.kind != floatNumber {
+		return new(big.Rat).Set(number.ratValue()), true
+	}
+	if math.IsInf(number.value, 0) || math.IsNaN(number.value) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(number.value, 'f', -1, 64))
+}
+
+// Fraction methods
+func numerator(receiver *SmalltalkNumber) (*SmalltalkNumber, error) {
+	value, ok := rationalOf(receiver)
+	if !ok {
+		return nil, errors.New(receiver.printString(10) + " has no numerator")
+	}
+	return NewSmalltalkLargeInteger(value.Num()), nil
+}
+
+func denominator(receiver *SmalltalkNumber) (*SmalltalkNumber, error) {
+	value, ok := rationalOf(receiver)
+	if !ok {
+		return nil, errors.New(receiver.printString(10) + " has no denominator")
+	}
+	return NewSmalltalkLargeInteger(value.Denom()), nil
+}
+
+func isFraction(receiver *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.kind == fraction)
+}
+
+func isScaledDecimal(receiver *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.kind == scaledDecimal)
+}
+
+func asScaledDecimal(receiver *SmalltalkNumber, scale *SmalltalkNumber) (*SmalltalkNumber, error) {
+	if scale.kind != smallInteger || scale.integer < 0 {
+		return nil, errors.New("scale must be a non negative integer, got " + scale.printString(10))
+	}
+	value, ok := rationalOf(receiver)
+	if !ok {
+		return nil, errors.New(receiver.printString(10) + " can not be a scaled decimal")
+	}
+	return NewSmalltalkScaledDecimal(value, int(scale.integer)), nil
+}
+
+// roundTo answers the multiple of quantum which is the nearest to the receiver. It has the kind and the scale of quantum,
+// so 3.14159s5 roundTo: 0.01s2 is 3.14s2.
+func roundTo(receiver *SmalltalkNumber, quantum *SmalltalkNumber) *SmalltalkNumber {
+	return mul(rounded(div(receiver, quantum)), quantum)
+}
+
+// displayString answers the text of the number for a user, so scaled decimals have no scale suffix and fractions have no parentheses
+func (n *SmalltalkNumber) displayString() string {
+	switch n.kind {
+	case fraction:
+		return n.rational.String()
+	case scaledDecimal:
+		return n.rational.FloatString(n.scale)
+	}
+	return n.printString(10)
+}
+
+// ratValue answers the value of an integer, a fraction or a scaled decimal as big.Rat
+func (n *SmalltalkNumber) ratValue() *big.Rat {
+	switch n.kind {
+	case fraction, scaledDecimal:
+		return n.rational
+	case largeInteger:
+		return new(big.Rat).SetInt(n.large)
+	}
+	return new(big.Rat).SetInt64(n.integer)
+}
+
+// GetRat answers the exact value of the number. Floats are converted from their decimal text, so 0.1 is 1/10.
+// Infinity and NaN answer false.
+func (n *SmalltalkNumber) GetRat() (*big.Rat, bool) {
+	return rationalOf(n)
+}
@@ -0,0 +1,420 @@
+package treeNodes
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const GO_OBJ = "GO"
+
+// goObjectMessages are understood by Go objects, unless their fields or methods have the same selectors
+var goObjectMessages = map[string]Method{
+	`=`:           binaryMethod(goObjectEquals),
+	`~=`:          binaryMethod(goObjectNotEquals),
+	`printString`: unaryMethod(goObjectPrintString),
+}
+
+// Go object methods
+func goObjectEquals(receiver *SmalltalkGoObject, other SmalltalkObjectInterface) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.equals(other))
+}
+
+func goObjectNotEquals(receiver *SmalltalkGoObject, other SmalltalkObjectInterface) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(!receiver.equals(other))
+}
+
+func goObjectPrintString(receiver *SmalltalkGoObject) *SmalltalkString {
+	return NewSmalltalkString(receiver.displayString())
+}
+
+// SmalltalkSelectors is implemented by Go types which rename methods for scripts. It answers selectors by
+// the names of methods, and the selector - hides a method. It is sent to the zero value of the type only once,
+// so it should answer the same selectors for all values. Fields are renamed with the smalltalk tag.
+type SmalltalkSelectors interface {
+	SmalltalkSelectors() map[string]string
+}
+
+var selectorsInterfaceType = reflect.TypeOf((*SmalltalkSelectors)(nil)).Elem()
+
+// goMember is a field or a method of a Go type which scripts can send
+type goMember struct {
+	field  []int
+	setter bool
+	method string
+}
+
+// goMembers keep members of Go types by selector, so every type is inspected only once
+var goMembers sync.Map
+
+// SmalltalkGoObject is a proxy of a Go value. Exported fields are read with unary messages and set with keyword
+// messages, like widget width and widget width: 10, and exported methods are sent with their arguments.
+type SmalltalkGoObject struct {
+	*SmalltalkObject
+	value   reflect.Value
+	members map[string]goMember
+}
+
+// NewSmalltalkGoObject answers a proxy of value. Fields of a struct are set only through a pointer to the struct.
+//
+// Selectors start with the name of the member in lower camel case, so the field URLPath is urlPath and urlPath:,
+// and the method Resize(width, height int) is resize:with:. A field with the tag smalltalk:"size" is size and
+// size:, and smalltalk:"-" hides the field. Methods are renamed and hidden by SmalltalkSelectors.
+// Variadic methods are not sent.
+func NewSmalltalkGoObject(value interface{}) (*SmalltalkGoObject, error) {
+	if value == nil {
+		return nil, errors.New(`we do not have Go objects for nil`)
+	}
+	reflectValue := reflect.ValueOf(value)
+	members, err := goMembersOf(reflectValue.Type())
+	if err != nil {
+		return nil, err
+	}
+	return &SmalltalkGoObject{&SmalltalkObject{}, reflectValue, members}, nil
+}
+
+func goMembersOf(valueType reflect.Type) (map[string]goMember, error) {
+	if members, ok := goMembers.Load(valueType); ok {
+		return members.(map[string]goMember), nil
+	}
+	members := map[string]goMember{}
+	add := func(selector string, member goMember) error {
+		if _, ok := members[selector]; ok {
+			return fmt.Errorf("%v has two members for #%s", valueType, selector)
+		}
+		members[selector] = member
+		return nil
+	}
+	structType := valueType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() == reflect.Struct {
+		for _, field := range reflect.VisibleFields(structType) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			selector := goSelectorName(field.Name)
+			if tag, ok := field.Tag.Lookup(`smalltalk`); ok {
+				if tag == `-` {
+					continue
+				}
+				if tag == "" || strings.Contains(tag, `:`) {
+					return nil, fmt.Errorf(`field %s of %v should have a unary selector in the smalltalk tag`, field.Name, valueType)
+				}
+				selector = tag
+			}
+			err := add(selector, goMember{field: field.Index})
+			if err != nil {
+				return nil, err
+			}
+			err = add(selector+`:`, goMember{field: field.Index, setter: true})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	var selectors map[string]string
+	if valueType.Implements(selectorsInterfaceType) {
+		zero := reflect.Zero(valueType)
+		if valueType.Kind() == reflect.Ptr {
+			zero = reflect.New(valueType.Elem())
+		}
+		selectors = zero.Interface().(SmalltalkSelectors).SmalltalkSelectors()
+	}
+	for i := 0; i < valueType.NumMethod(); i++ {
+		method := valueType.Method(i)
+		if method.Name == `SmalltalkSelectors` || method.Type.IsVariadic() {
+			continue
+		}
+		arity := method.Type.NumIn() - 1
+		selector, ok := selectors[method.Name]
+		if !ok {
+			selector = goSelectorName(method.Name)
+			if arity > 0 {
+				selector += `:` + strings.Repeat(`with:`, arity-1)
+			}
+		}
+		if selector == `-` {
+			continue
+		}
+		if SelectorArity(selector) != arity {
+			return nil, fmt.Errorf(`selector #%s of method %s of %v should have %d arguments`, selector, method.Name, valueType, arity)
+		}
+		err := add(selector, goMember{method: method.Name})
+		if err != nil {
+			return nil, err
+		}
+	}
+	goMembers.Store(valueType, members)
+	return members, nil
+}
+
+// goSelectorName answers name with the leading capitals in lower case, like url for URL and urlPath for URLPath
+func goSelectorName(name string) string {
+	runes := []rune(name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// GetValue answers the Go value of the proxy
+func (g *SmalltalkGoObject) GetValue() interface{} {
+	return g.value.Interface()
+}
+
+func (g *SmalltalkGoObject) equals(other SmalltalkObjectInterface) bool {
+	typedOther, ok := other.(*SmalltalkGoObject)
+	if !ok || g.value.Type() != typedOther.value.Type() || !g.value.Type().Comparable() {
+		return g == other
+	}
+	return g.value.Interface() == typedOther.value.Interface()
+}
+
+func (g *SmalltalkGoObject) displayString() string {
+	valueType := g.value.Type()
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if valueType.Name() == "" {
+		return withArticle(valueType.String())
+	}
+	return withArticle(valueType.Name())
+}
+
+func (g *SmalltalkGoObject) Value() SmalltalkObjectInterface {
+	return g
+}
+
+func (g *SmalltalkGoObject) TypeOf() string {
+	return GO_OBJ
+}
+
+func (g *SmalltalkGoObject) respondsTo(selector string) bool {
+	_, isMember := g.members[selector]
+	return isMember || lookupMethod(goObjectMessages, selector) != nil
+}
+
+func (g *SmalltalkGoObject) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	member, ok := g.members[name]
+	if !ok {
+		return performMethod(g, goObjectMessages, name, params)
+	}
+	args, err := deferredValues(params)
+	if err != nil {
+		return nil, err
+	}
+	if member.method != "" {
+		return g.call(g.value.MethodByName(member.method), name, args)
+	}
+	if member.setter {
+		return g.setField(member.field, name, args)
+	}
+	return g.getField(member.field, args)
+}
+
+func (g *SmalltalkGoObject) fieldOf(index []int) (reflect.Value, error) {
+	structValue := g.value
+	if structValue.Kind() == reflect.Ptr {
+		if structValue.IsNil() {
+			return reflect.Value{}, errors.New(`we do not have fields of a nil ` + g.value.Type().String())
+		}
+		structValue = structValue.Elem()
+	}
+	return structValue.FieldByIndexErr(index)
+}
+
+// getField answers the field value. Struct fields are answered as proxies of the field, so scripts change them in place.
+func (g *SmalltalkGoObject) getField(index []int, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	err := checkArgumentsCount(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	field, err := g.fieldOf(index)
+	if err != nil {
+		return nil, err
+	}
+	if field.Kind() == reflect.Struct && field.CanAddr() {
+		field = field.Addr()
+	}
+	return NewSmalltalkObjectFrom(field.Interface())
+}
+
+// setField sets the field to the argument converted to the field type and answers the receiver
+func (g *SmalltalkGoObject) setField(index []int, name string, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	err := checkArgumentsCount(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	field, err := g.fieldOf(index)
+	if err != nil {
+		return nil, err
+	}
+	if !field.CanSet() {
+		return nil, errors.New(`we do not set #` + name + ` of ` + g.value.Type().String() + `, give a pointer to the struct to set its fields`)
+	}
+	value, err := goValueFor(args[0], field.Type())
+	if err != nil {
+		return nil, err
+	}
+	field.Set(value)
+	return g, nil
+}
+
+// call calls method with the arguments converted to its parameter types. A method without results answers
+// the receiver, a non nil error result is the error of the send and several results are an Array. A panic of
+// the method is a GoPanicError.
+func (g *SmalltalkGoObject) call(method reflect.Value, name string, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	methodType := method.Type()
+	if len(args) != methodType.NumIn() {
+		return nil, &WrongArgumentCountError{Selector: name, Expected: methodType.NumIn(), Actual: len(args)}
+	}
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		value, err := goValueFor(arg, methodType.In(i))
+		if err != nil {
+			return nil, err
+		}
+		in[i] = value
+	}
+	results, err := callRecovering(method, name, in)
+	if err != nil {
+		return nil, err
+	}
+	if count := len(results); count > 0 && methodType.Out(count-1) == errorInterfaceType {
+		if !results[count-1].IsNil() {
+			return nil, results[count-1].Interface().(error)
+		}
+		results = results[:count-1]
+	}
+	switch len(results) {
+	case 0:
+		return g, nil
+	case 1:
+		return NewSmalltalkObjectFrom(results[0].Interface())
+	}
+	elements := make([]SmalltalkObjectInterface, len(results))
+	for i, each := range results {
+		element, err := NewSmalltalkObjectFrom(each.Interface())
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = element
+	}
+	return NewSmalltalkArray(elements), nil
+}
+
+// callRecovering calls method and answers its panic as an error, so scripts handle it like other errors
+func callRecovering(method reflect.Value, name string, in []reflect.Value) (results []reflect.Value, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &GoPanicError{Selector: name, Recovered: recovered, Stack: debug.Stack()}
+		}
+	}()
+	return method.Call(in), nil
+}
+
+// goValueFor answers object converted to a Go value of valueType. Smalltalk objects are given as they are to
+// parameters of smalltalk object types, and proxies give their Go values.
+func goValueFor(object SmalltalkObjectInterface, valueType reflect.Type) (reflect.Value, error) {
+	if valueType.Implements(objectInterfaceType) && reflect.TypeOf(object).AssignableTo(valueType) {
+		return reflect.ValueOf(object), nil
+	}
+	if goObject, ok := object.(*SmalltalkGoObject); ok {
+		if goObject.value.Type().AssignableTo(valueType) {
+			return goObject.value, nil
+		}
+		if goObject.value.Kind() == reflect.Ptr && !goObject.value.IsNil() && goObject.value.Type().Elem().AssignableTo(valueType) {
+			return goObject.value.Elem(), nil
+		}
+		return reflect.Value{}, &TypeMismatchError{Expected: valueType.String(), Actual: object.TypeOf()}
+	}
+	value, err := InterfaceValue(object)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	converted, ok := convertedGoValue(value, valueType)
+	if !ok {
+		return reflect.Value{}, &TypeMismatchError{Expected: valueType.String(), Actual: object.TypeOf()}
+	}
+	return converted, nil
+}
+
+// convertedGoValue converts values answered by InterfaceValue. Numbers are converted to other number types
+// only without loss, except to floats, and elements of slices and maps are converted one by one.
+func convertedGoValue(value interface{}, valueType reflect.Type) (reflect.Value, bool) {
+	if value == nil {
+		switch valueType.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(valueType), true
+		}
+		return reflect.Value{}, false
+	}
+	reflectValue := reflect.ValueOf(value)
+	if reflectValue.Type().AssignableTo(valueType) {
+		return reflectValue, true
+	}
+	switch {
+	case isGoNumber(reflectValue.Kind()) && isGoNumber(valueType.Kind()):
+		converted := reflectValue.Convert(valueType)
+		switch valueType.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return converted, true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if isNegativeGoNumber(reflectValue) {
+				return reflect.Value{}, false
+			}
+		}
+		return converted, converted.Convert(reflectValue.Type()).Interface() == value
+	case reflectValue.Kind() == reflect.Slice && valueType.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(valueType, reflectValue.Len(), reflectValue.Len())
+		for i := 0; i < reflectValue.Len(); i++ {
+			element, ok := convertedGoValue(reflectValue.Index(i).Interface(), valueType.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			slice.Index(i).Set(element)
+		}
+		return slice, true
+	case reflectValue.Kind() == reflect.Map && valueType.Kind() == reflect.Map && valueType.Key().Kind() == reflect.String:
+		dictionary := reflect.MakeMapWithSize(valueType, reflectValue.Len())
+		iterator := reflectValue.MapRange()
+		for iterator.Next() {
+			element, ok := convertedGoValue(iterator.Value().Interface(), valueType.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			dictionary.SetMapIndex(iterator.Key().Convert(valueType.Key()), element)
+		}
+		return dictionary, true
+	}
+	return reflect.Value{}, false
+}
+
+func isGoNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isNegativeGoNumber(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() < 0
+	}
+	return false
+}
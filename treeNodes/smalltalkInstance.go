@@ -0,0 +1,128 @@
+package treeNodes
+
+const INSTANCE_OBJ = "INSTANCE"
+
+// instanceMessages are understood by instances of classes which scripts define, unless their methods replace them
+var instanceMessages = map[string]Method{
+	`class`:       unaryMethod(instanceClass),
+	`=`:           binaryMethod(identical),
+	`~=`:          binaryMethod(notIdentical),
+	`isKindOf:`:   binaryMethod(isKindOf),
+	`printString`: unaryMethod(instancePrintString),
+}
+
+// Instance methods
+func instanceClass(receiver *SmalltalkInstance) *SmalltalkClass {
+	return receiver.class
+}
+
+func isKindOf(receiver *SmalltalkInstance, class SmalltalkObjectInterface) *SmalltalkBoolean {
+	typedClass, ok := class.(*SmalltalkClass)
+	return NewSmalltalkBoolean(ok && receiver.class.IncludesBehavior(typedClass))
+}
+
+func instancePrintString(receiver *SmalltalkInstance) *SmalltalkString {
+	return NewSmalltalkString(withArticle(receiver.class.GetName()))
+}
+
+// scriptObject is an object whose class may be defined by a script. Methods of the class are evaluated with
+// the object as self, and a super send looks up the method starting at the superclass of the method class.
+type scriptObject interface {
+	SmalltalkObjectInterface
+	performFrom(start *SmalltalkClass, name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error)
+}
+
+// performInstanceMethod evaluates the method for name of start or of its superclasses. Objects without
+// such a method answer the message with builtins.
+func performInstanceMethod(receiver SmalltalkObjectInterface, start *SmalltalkClass, variables map[string]SmalltalkObjectInterface,
+	builtins map[string]Method, name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if start != nil {
+		if method, class := start.methodAndClassFor(name); method != nil {
+			args, err := deferredValues(params)
+			if err != nil {
+				return nil, err
+			}
+			return activateMethod(method, class, receiver, variables, args)
+		}
+	}
+	return performMethod(receiver, builtins, name, params)
+}
+
+// activateMethod evaluates method of class for receiver as a new activation. The method sees its arguments and
+// temporaries, instance variables of receiver, class variables and the variables of the scope the class was
+// defined in. A method without ^ answers self.
+func activateMethod(method *MethodNode, class *SmalltalkClass, receiver SmalltalkObjectInterface,
+	variables map[string]SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if len(args) != len(method.arguments) {
+		return nil, &WrongArgumentCountError{Selector: method.selector, Expected: len(method.arguments), Actual: len(args)}
+	}
+	context := &Context{receiver: receiver, methodClass: class, selector: method.selector}
+	defer func() {
+		context.finished = true
+	}()
+	outer := class.variablesScope(context)
+	if len(variables) > 0 {
+		outer = &Scope{variables: variables, OuterScope: outer, context: context}
+	}
+	scope := new(Scope).Initialize()
+	scope.OuterScope = outer
+	scope.SetContext(context)
+	for i, arg := range args {
+		scope.SetVar(method.arguments[i].GetName(), arg)
+	}
+	_, err := method.body.Eval(scope)
+	if err != nil {
+		if ret, ok := err.(*nonLocalReturn); ok && ret.home == context {
+			return ret.value, nil
+		}
+		return nil, err
+	}
+	return receiver, nil
+}
+
+// SmalltalkInstance is an instance of a class which a script defines. It keeps its instance variables by name.
+type SmalltalkInstance struct {
+	*SmalltalkObject
+	class     *SmalltalkClass
+	variables map[string]SmalltalkObjectInterface
+}
+
+func (i *SmalltalkInstance) GetClass() *SmalltalkClass {
+	return i.class
+}
+
+// GetVariable answers the value of the instance variable name and whether the instance has it
+func (i *SmalltalkInstance) GetVariable(name string) (SmalltalkObjectInterface, bool) {
+	value, ok := i.variables[name]
+	return value, ok
+}
+
+// displayString answers the result of printString when the class defines it
+func (i *SmalltalkInstance) displayString() string {
+	if i.class.methodFor(`printString`) != nil {
+		if text, err := i.Perform(`printString`, nil); err == nil {
+			return displayString(text)
+		}
+	}
+	return withArticle(i.class.GetName())
+}
+
+func (i *SmalltalkInstance) Value() SmalltalkObjectInterface {
+	return i
+}
+
+func (i *SmalltalkInstance) TypeOf() string {
+	return INSTANCE_OBJ
+}
+
+func (i *SmalltalkInstance) respondsTo(selector string) bool {
+	return i.class.understands(selector)
+}
+
+func (i *SmalltalkInstance) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return i.performFrom(i.class, name, params)
+}
+
+func (i *SmalltalkInstance) performFrom(start *SmalltalkClass, name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performInstanceMethod(i, start, i.variables, instanceMessages, name, params)
+}
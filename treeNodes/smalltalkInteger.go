@@ -0,0 +1,430 @@
+package treeNodes
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// numberKind tells how a number keeps its value. Floats are the zero kind, so new(SmalltalkNumber) is 0.0.
+type numberKind int
+
+const (
+	floatNumber numberKind = iota
+	smallInteger
+	largeInteger
+	fraction
+	scaledDecimal
+)
+
+// NewSmalltalkInteger answers a SmallInteger
+func NewSmalltalkInteger(value int64) *SmalltalkNumber {
+	return &SmalltalkNumber{SmalltalkObject: &SmalltalkObject{}, value: float64(value), kind: smallInteger, integer: value}
+}
+
+// NewSmalltalkLargeInteger answers a LargeInteger or a SmallInteger if value fits int64
+func NewSmalltalkLargeInteger(value *big.Int) *SmalltalkNumber {
+	if value.IsInt64() {
+		return NewSmalltalkInteger(value.Int64())
+	}
+	approximation, _ := new(big.Float).SetInt(value).Float64()
+	return &SmalltalkNumber{SmalltalkObject: &SmalltalkObject{}, value: approximation, kind: largeInteger, large: value}
+}
+
+// parseNumber answers an integer if text has only digits, a ScaledDecimal if text looks like 3.14s2 and a float otherwise
+func parseNumber(text string) (*SmalltalkNumber, error) {
+	if digits, scale, ok := strings.Cut(text, "s"); ok {
+		return parseScaledDecimal(digits, scale)
+	}
+	integer, ok := new(big.Int).SetString(text, 10)
+	if ok {
+		return NewSmalltalkLargeInteger(integer), nil
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, err
+	}
+	return NewSmalltalkNumber(number), nil
+}
+
+// floatToInteger answers the integer part of value. Infinity and NaN have no integer part, so they are answered as they are.
+func floatToInteger(value float64) *SmalltalkNumber {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return NewSmalltalkNumber(value)
+	}
+	if value >= math.MinInt64 && value < math.MaxInt64 {
+		return NewSmalltalkInteger(int64(value))
+	}
+	integer, _ := big.NewFloat(value).Int(nil)
+	return NewSmalltalkLargeInteger(integer)
+}
+
+// arithmetic answers the result of the small operation if both numbers are SmallIntegers and it does not overflow,
+// of the large operation if both numbers are integers, of the rational operation if none of them is a float and
+// of the float operation otherwise. Large and rational operations answer nil when they have no exact result.
+// Like in Pharo a Fraction and an Integer give a Fraction and a ScaledDecimal gives a ScaledDecimal with the biggest scale.
+func arithmetic(receiver *SmalltalkNumber, arg *SmalltalkNumber,
+	small func(int64, int64) (int64, bool), large func(*big.Int, *big.Int) *big.Int,
+	rational func(*big.Rat, *big.Rat) *big.Rat, float func(float64, float64) float64) *SmalltalkNumber {
+	if receiver.kind == smallInteger && arg.kind == smallInteger {
+		result, ok := small(receiver.integer, arg.integer)
+		if ok {
+			return NewSmalltalkInteger(result)
+		}
+	}
+	if receiver.IsInteger() && arg.IsInteger() {
+		result := large(receiver.bigValue(), arg.bigValue())
+		if result != nil {
+			return NewSmalltalkLargeInteger(result)
+		}
+	}
+	if receiver.kind != floatNumber && arg.kind != floatNumber {
+		result := rational(receiver.ratValue(), arg.ratValue())
+		if result != nil {
+			return exactNumber(result, receiver, arg)
+		}
+	}
+	return NewSmalltalkNumber(float(receiver.value, arg.value))
+}
+
+// compare answers -1, 0 or +1 and false if the numbers are unordered because one of them is NaN.
+// Integers are compared exactly.
+func compare(receiver *SmalltalkNumber, arg *SmalltalkNumber) (int, bool) {
+	if receiver.kind == smallInteger && arg.kind == smallInteger {
+		switch {
+		case receiver.integer < arg.integer:
+			return -1, true
+		case receiver.integer > arg.integer:
+			return 1, true
+		}
+		return 0, true
+	}
+	if receiver.IsInteger() && arg.IsInteger() {
+		return receiver.bigValue().Cmp(arg.bigValue()), true
+	}
+	if receiver.kind != floatNumber && arg.kind != floatNumber {
+		return receiver.ratValue().Cmp(arg.ratValue()), true
+	}
+	switch {
+	case receiver.value < arg.value:
+		return -1, true
+	case receiver.value > arg.value:
+		return 1, true
+	case receiver.value == arg.value:
+		return 0, true
+	}
+	return 0, false
+}
+
+func addInt64(a int64, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (sum > a) == (b > 0)
+}
+
+func subInt64(a int64, b int64) (int64, bool) {
+	difference := a - b
+	return difference, (difference < a) == (b > 0)
+}
+
+func mulInt64(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	return product, product/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+}
+
+// exactDivInt64 divides only without a remainder, otherwise div answers the quotient as a Fraction
+func exactDivInt64(a int64, b int64) (int64, bool) {
+	if b == 0 || (a == math.MinInt64 && b == -1) || a%b != 0 {
+		return 0, false
+	}
+	return a / b, true
+}
+
+func exactDivBig(a *big.Int, b *big.Int) *big.Int {
+	if b.Sign() == 0 {
+		return nil
+	}
+	quotient, remainder := new(big.Int).QuoRem(a, b, new(big.Int))
+	if remainder.Sign() != 0 {
+		return nil
+	}
+	return quotient
+}
+
+// floorDivInt64 answers the quotient rounded towards negative infinity like //
+func floorDivInt64(a int64, b int64) (int64, bool) {
+	if b == 0 || (a == math.MinInt64 && b == -1) {
+		return 0, false
+	}
+	quotient := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		quotient--
+	}
+	return quotient, true
+}
+
+func floorDivBig(a *big.Int, b *big.Int) *big.Int {
+	if b.Sign() == 0 {
+		return nil
+	}
+	quotient, remainder := new(big.Int).QuoRem(a, b, new(big.Int))
+	if remainder.Sign() != 0 && remainder.Sign() != b.Sign() {
+		quotient.Sub(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// floorModInt64 answers the remainder which has the sign of the divisor like \\
+func floorModInt64(a int64, b int64) (int64, bool) {
+	if b == 0 || b == -1 {
+		return 0, b == -1
+	}
+	remainder := a % b
+	if remainder != 0 && (remainder < 0) != (b < 0) {
+		remainder += b
+	}
+	return remainder, true
+}
+
+func floorModBig(a *big.Int, b *big.Int) *big.Int {
+	if b.Sign() == 0 {
+		return nil
+	}
+	remainder := new(big.Int).Rem(a, b)
+	if remainder.Sign() != 0 && remainder.Sign() != b.Sign() {
+		remainder.Add(remainder, b)
+	}
+	return remainder
+}
+
+func floorModFloat(a float64, b float64) float64 {
+	return a - math.Floor(a/b)*b
+}
+
+// remInt64 answers the remainder which has the sign of the receiver like rem:
+func remInt64(a int64, b int64) (int64, bool) {
+	if b == 0 || b == -1 {
+		return 0, b == -1
+	}
+	return a % b, true
+}
+
+func remBig(a *big.Int, b *big.Int) *big.Int {
+	if b.Sign() == 0 {
+		return nil
+	}
+	return new(big.Int).Rem(a, b)
+}
+
+func notAnInteger(numbers ...*SmalltalkNumber) error {
+	for _, each := range numbers {
+		if !each.IsInteger() {
+			return &NotAnIntegerError{Number: each.printString(10)}
+		}
+	}
+	return nil
+}
+
+// Integer methods
+func isInteger(receiver *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.IsInteger())
+}
+
+func isFloat(receiver *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.kind == floatNumber)
+}
+
+func asFloat(receiver *SmalltalkNumber) *SmalltalkNumber {
+	if receiver.kind == floatNumber {
+		return receiver
+	}
+	return NewSmalltalkNumber(receiver.value)
+}
+
+func asInteger(receiver *SmalltalkNumber) *SmalltalkNumber {
+	return truncated(receiver)
+}
+
+func gcd(receiver *SmalltalkNumber, arg *SmalltalkNumber) (*SmalltalkNumber, error) {
+	err := notAnInteger(receiver, arg)
+	if err != nil {
+		return nil, err
+	}
+	return NewSmalltalkLargeInteger(new(big.Int).GCD(nil, nil, receiver.bigValue(), arg.bigValue())), nil
+}
+
+func lcm(receiver *SmalltalkNumber, arg *SmalltalkNumber) (*SmalltalkNumber, error) {
+	divisor, err := gcd(receiver, arg)
+	if err != nil {
+		return nil, err
+	}
+	if divisor.bigValue().Sign() == 0 {
+		return NewSmalltalkInteger(0), nil
+	}
+	product := new(big.Int).Mul(receiver.bigValue(), arg.bigValue())
+	return NewSmalltalkLargeInteger(product.Abs(product.Quo(product, divisor.bigValue()))), nil
+}
+
+func factorial(receiver *SmalltalkNumber) (*SmalltalkNumber, error) {
+	err := notAnInteger(receiver)
+	if err != nil {
+		return nil, err
+	}
+	if receiver.kind != smallInteger || receiver.integer < 0 {
+		return nil, errors.New("factorial is defined for non negative integers only, got " + receiver.printString(10))
+	}
+	return NewSmalltalkLargeInteger(new(big.Int).MulRange(1, receiver.integer)), nil
+}
+
+func bitAnd(receiver *SmalltalkNumber, arg *SmalltalkNumber) (*SmalltalkNumber, error) {
+	err := notAnInteger(receiver, arg)
+	if err != nil {
+		return nil, err
+	}
+	if receiver.kind == smallInteger && arg.kind == smallInteger {
+		return NewSmalltalkInteger(receiver.integer & arg.integer), nil
+	}
+	return NewSmalltalkLargeInteger(new(big.Int).And(receiver.bigValue(), arg.bigValue())), nil
+}
+
+func bitOr(receiver *SmalltalkNumber, arg *SmalltalkNumber) (*SmalltalkNumber, error) {
+	err := notAnInteger(receiver, arg)
+	if err != nil {
+		return nil, err
+	}
+	if receiver.kind == smallInteger && arg.kind == smallInteger {
+		return NewSmalltalkInteger(receiver.integer | arg.integer), nil
+	}
+	return NewSmalltalkLargeInteger(new(big.Int).Or(receiver.bigValue(), arg.bigValue())), nil
+}
+
+// bitShift shifts left for positive arg and right for negative arg
+func bitShift(receiver *SmalltalkNumber, arg *SmalltalkNumber) (*SmalltalkNumber, error) {
+	err := notAnInteger(receiver, arg)
+	if err != nil {
+		return nil, err
+	}
+	if arg.kind != smallInteger {
+		return nil, errors.New("bitShift: is too big: " + arg.printString(10))
+	}
+	shift := arg.integer
+	if receiver.kind == smallInteger {
+		switch {
+		case shift <= 0 && shift > -64:
+			return NewSmalltalkInteger(receiver.integer >> uint(-shift)), nil
+		case shift <= -64:
+			return NewSmalltalkInteger(receiver.integer >> 63), nil
+		case shift < 63 && (receiver.integer<<uint(shift))>>uint(shift) == receiver.integer:
+			return NewSmalltalkInteger(receiver.integer << uint(shift)), nil
+		}
+	}
+	if shift >= 0 {
+		return NewSmalltalkLargeInteger(new(big.Int).Lsh(receiver.bigValue(), uint(shift))), nil
+	}
+	return NewSmalltalkLargeInteger(new(big.Int).Rsh(receiver.bigValue(), uint(-shift))), nil
+}
+
+func numberPrintString(receiver *SmalltalkNumber) *SmalltalkString {
+	return NewSmalltalkString(receiver.printString(10))
+}
+
+func numberPrintStringRadix(receiver *SmalltalkNumber, radix *SmalltalkNumber) (*SmalltalkString, error) {
+	if radix.kind != smallInteger || radix.integer < 2 || radix.integer > 36 {
+		return nil, errors.New("radix must be an integer from 2 to 36, got " + radix.printString(10))
+	}
+	if !receiver.IsInteger() && radix.integer != 10 {
+		return nil, errors.New("only integers can be printed with radix " + radix.printString(10))
+	}
+	return NewSmalltalkString(receiver.printString(int(radix.integer))), nil
+}
+
+// printString answers the text of the number. Floats always have a fraction part, so 2.0 is not printed as 2.
+func (n *SmalltalkNumber) printString(radix int) string {
+	switch n.kind {
+	case smallInteger:
+		return strings.ToUpper(strconv.FormatInt(n.integer, radix))
+	case largeInteger:
+		return strings.ToUpper(n.large.Text(radix))
+	case fraction:
+		return "(" + n.rational.String() + ")"
+	case scaledDecimal:
+		return n.rational.FloatString(n.scale) + "s" + strconv.Itoa(n.scale)
+	}
+	text := strconv.FormatFloat(n.value, 'f', -1, 64)
+	if !math.IsInf(n.value, 0) && !math.IsNaN(n.value) && !strings.Contains(text, ".") {
+		text += ".0"
+	}
+	return text
+}
+
+// PrintString answers the text of the number the way Smalltalk prints it
+func (n *SmalltalkNumber) PrintString() string {
+	return n.printString(10)
+}
+
+// bigValue answers the value of an integer as big.Int
+func (n *SmalltalkNumber) bigValue() *big.Int {
+	if n.kind == largeInteger {
+		return n.large
+	}
+	return big.NewInt(n.integer)
+}
+
+// IsInteger answers whether the number is a SmallInteger or a LargeInteger
+func (n *SmalltalkNumber) IsInteger() bool {
+	return n.kind == smallInteger || n.kind == largeInteger
+}
+
+// GetInt64 answers the value of the number and true if it is an integer which fits int64 or a float without a fraction part
+func (n *SmalltalkNumber) GetInt64() (int64, bool) {
+	switch n.kind {
+	case smallInteger:
+		return n.integer, true
+	case largeInteger:
+		return 0, false
+	case fraction, scaledDecimal:
+		if !n.rational.IsInt() || !n.rational.Num().IsInt64() {
+			return 0, false
+		}
+		return n.rational.Num().Int64(), true
+	}
+	if n.value != math.Trunc(n.value) || n.value < math.MinInt64 || n.value >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(n.value), true
+}
+
+// GetBigInt answers the value of an integer and false for other numbers
+func (n *SmalltalkNumber) GetBigInt() (*big.Int, bool) {
+	if !n.IsInteger() {
+		return nil, false
+	}
+	return new(big.Int).Set(n.bigValue()), true
+}
+
+// GetInterfaceValue answers float64 for SmallIntegers and floats, *big.Int for LargeIntegers and *big.Rat for fractions
+// and scaled decimals. Use GetExactInterfaceValue to get SmallIntegers as int64.
+func (n *SmalltalkNumber) GetInterfaceValue() interface{} {
+	if n.kind == smallInteger {
+		return n.value
+	}
+	return n.GetExactInterfaceValue()
+}
+
+// GetExactInterfaceValue answers int64 for SmallIntegers and otherwise the same values as GetInterfaceValue, so integers
+// which float64 can not represent exactly keep their value
+func (n *SmalltalkNumber) GetExactInterfaceValue() interface{} {
+	switch n.kind {
+	case smallInteger:
+		return n.integer
+	case largeInteger:
+		return new(big.Int).Set(n.large)
+	case fraction, scaledDecimal:
+		return new(big.Rat).Set(n.rational)
+	}
+	return n.value
+}
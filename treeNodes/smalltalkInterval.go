@@ -0,0 +1,213 @@
+package treeNodes
+
+import (
+	"errors"
+	"math"
+)
+
+const INTERVAL_OBJ = "INTERVAL"
+
+var intervalMessages = withCollectionMessages(map[string]Method{
+	`value`:        unaryMethodE(value),
+	`size`:         unaryMethod(intervalSize),
+	`first`:        unaryMethodE(intervalFirst),
+	`last`:         unaryMethodE(intervalLast),
+	`at:`:          binaryMethodE(intervalAt),
+	`do:`:          binaryMethodE(intervalDo),
+	`collect:`:     binaryMethodE(intervalCollect),
+	`select:`:      binaryMethodE(intervalSelect),
+	`inject:into:`: ternaryMethodE(intervalInjectInto),
+	`asArray`:      unaryMethod(intervalAsArray),
+}, sequenceableMessages)
+
+// Number methods which iterate
+func toDo(receiver *SmalltalkNumber, stop *SmalltalkNumber, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	err := NewSmalltalkInterval(receiver, stop, NewSmalltalkInteger(1)).do(block)
+	if err != nil {
+		return nil, err
+	}
+	return receiver, nil
+}
+
+func toByDo(receiver *SmalltalkNumber, stop *SmalltalkNumber, step *SmalltalkNumber, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	if step.value == 0 {
+		return nil, errors.New("step must not be zero")
+	}
+	err := NewSmalltalkInterval(receiver, stop, step).do(block)
+	if err != nil {
+		return nil, err
+	}
+	return receiver, nil
+}
+
+func timesRepeat(receiver *SmalltalkNumber, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	for i := 1; i <= int(receiver.value); i++ {
+		_, err := block.ValueWithArguments(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receiver, nil
+}
+
+func betweenAnd(receiver *SmalltalkNumber, min *SmalltalkNumber, max *SmalltalkNumber) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(lesserEqual(min, receiver).value && lesserEqual(receiver, max).value)
+}
+
+func to(receiver *SmalltalkNumber, stop *SmalltalkNumber) *SmalltalkInterval {
+	return NewSmalltalkInterval(receiver, stop, NewSmalltalkInteger(1))
+}
+
+func toBy(receiver *SmalltalkNumber, stop *SmalltalkNumber, step *SmalltalkNumber) (*SmalltalkInterval, error) {
+	if step.value == 0 {
+		return nil, errors.New("step must not be zero")
+	}
+	return NewSmalltalkInterval(receiver, stop, step), nil
+}
+
+// Interval methods
+func intervalSize(receiver *SmalltalkInterval) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(receiver.Size()))
+}
+
+func intervalFirst(receiver *SmalltalkInterval) (*SmalltalkNumber, error) {
+	return intervalAt(receiver, NewSmalltalkInteger(1))
+}
+
+func intervalLast(receiver *SmalltalkInterval) (*SmalltalkNumber, error) {
+	return intervalAt(receiver, NewSmalltalkInteger(int64(receiver.Size())))
+}
+
+func intervalAt(receiver *SmalltalkInterval, index *SmalltalkNumber) (*SmalltalkNumber, error) {
+	i, err := offsetOf(index, receiver.Size())
+	if err != nil {
+		return nil, err
+	}
+	return receiver.at(i), nil
+}
+
+func intervalDo(receiver *SmalltalkInterval, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	err := receiver.do(block)
+	if err != nil {
+		return nil, err
+	}
+	return receiver, nil
+}
+
+func intervalCollect(receiver *SmalltalkInterval, block *SmalltalkBlock) (*SmalltalkArray, error) {
+	result := make([]SmalltalkObjectInterface, receiver.Size())
+	for i := range result {
+		element, err := block.ValueWithArguments([]SmalltalkObjectInterface{receiver.at(i)})
+		if err != nil {
+			return nil, err
+		}
+		result[i] = element
+	}
+	return NewSmalltalkArray(result), nil
+}
+
+func intervalSelect(receiver *SmalltalkInterval, block *SmalltalkBlock) (*SmalltalkArray, error) {
+	var result []SmalltalkObjectInterface
+	for i := 0; i < receiver.Size(); i++ {
+		element := receiver.at(i)
+		selected, err := block.ValueWithArguments([]SmalltalkObjectInterface{element})
+		if err != nil {
+			return nil, err
+		}
+		boolean, ok := selected.(*SmalltalkBoolean)
+		if !ok {
+			return nil, &TypeMismatchError{Expected: BOOLEAN_OBJ, Actual: selected.TypeOf()}
+		}
+		if boolean.GetValue() {
+			result = append(result, element)
+		}
+	}
+	return NewSmalltalkArray(result), nil
+}
+
+func intervalInjectInto(receiver *SmalltalkInterval, initial SmalltalkObjectInterface, block *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	accumulator := initial
+	for i := 0; i < receiver.Size(); i++ {
+		next, err := block.ValueWithArguments([]SmalltalkObjectInterface{accumulator, receiver.at(i)})
+		if err != nil {
+			return nil, err
+		}
+		accumulator = next
+	}
+	return accumulator, nil
+}
+
+func intervalAsArray(receiver *SmalltalkInterval) *SmalltalkArray {
+	result := make([]SmalltalkObjectInterface, receiver.Size())
+	for i := range result {
+		result[i] = receiver.at(i)
+	}
+	return NewSmalltalkArray(result)
+}
+
+// SmalltalkInterval is an arithmetic progression of numbers from start to stop, e.g. 1 to: 10 by: 2
+type SmalltalkInterval struct {
+	*SmalltalkObject
+	start *SmalltalkNumber
+	stop  *SmalltalkNumber
+	step  *SmalltalkNumber
+}
+
+func NewSmalltalkInterval(start *SmalltalkNumber, stop *SmalltalkNumber, step *SmalltalkNumber) *SmalltalkInterval {
+	return &SmalltalkInterval{&SmalltalkObject{}, start, stop, step}
+}
+
+func (i *SmalltalkInterval) Size() int {
+	size := math.Floor((i.stop.value-i.start.value)/i.step.value) + 1
+	if size < 0 {
+		return 0
+	}
+	return int(size)
+}
+
+// at answers the element with zero based offset. Elements are computed from start to avoid accumulating errors.
+func (i *SmalltalkInterval) at(offset int) *SmalltalkNumber {
+	return plus(i.start, mul(NewSmalltalkInteger(int64(offset)), i.step))
+}
+
+func (i *SmalltalkInterval) do(block *SmalltalkBlock) error {
+	for offset := 0; offset < i.Size(); offset++ {
+		_, err := block.ValueWithArguments([]SmalltalkObjectInterface{i.at(offset)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *SmalltalkInterval) elements() []SmalltalkObjectInterface {
+	elements := make([]SmalltalkObjectInterface, i.Size())
+	for offset := range elements {
+		elements[offset] = i.at(offset)
+	}
+	return elements
+}
+
+func (i *SmalltalkInterval) species(elements []SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return NewSmalltalkArray(elements)
+}
+
+func (i *SmalltalkInterval) GetValue() []interface{} {
+	values := make([]interface{}, i.Size())
+	for offset := range values {
+		values[offset] = i.at(offset).GetInterfaceValue()
+	}
+	return values
+}
+
+func (i *SmalltalkInterval) Value() SmalltalkObjectInterface {
+	return i
+}
+
+func (i *SmalltalkInterval) TypeOf() string {
+	return INTERVAL_OBJ
+}
+
+func (i *SmalltalkInterval) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(i, intervalMessages, name, params)
+}
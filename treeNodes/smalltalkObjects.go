@@ -3,151 +3,427 @@ package treeNodes
 import (
 	"errors"
 	"math"
+	"math/big"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
 )
 
 const (
 	NUMBER_OBJ    = "NUMBER"
 	BOOLEAN_OBJ   = "BOOLEAN"
 	STRING_OBJ    = "STRING"
+	SYMBOL_OBJ    = "SYMBOL"
 	BLOCK_OBJ     = "BLOCK"
 	DEFERRED      = "DEFERRED"
 	ARRAY_OBJ     = "ARRAY"
 	UNDEFINED_OBJ = "UNDEFINED"
+	OBJECT_OBJ    = "OBJECT"
 )
 
-var numberMessages = map[string]interface{}{
-	`value`:            value,
-	`=`:                equal,
-	`~=`:               notEqual,
-	`>`:                greater,
-	`>=`:               greaterEqual,
-	`<`:                lesser,
-	`<=`:               lesserEqual,
-	`+`:                plus,
-	`-`:                minus,
-	`*`:                mul,
-	`/`:                div,
-	`\\`:               mod,
-	`//`:               intDiv,
-	`rem:`:             rem,
-	`max:`:             max,
-	`min:`:             min,
-	`abs`:              abs,
-	`sqrt`:             sqrt,
-	`sqr`:              sqr,
-	`sin`:              sin,
-	`cos`:              cos,
-	`tan`:              tan,
-	`arcSin`:           arcSin,
-	`arcCos`:           arcCos,
-	`arcTan`:           arcTan,
-	`rounded`:          rounded,
-	`truncated`:        truncated,
-	`fractionPart`:     fractionPart,
-	`floor`:            floor,
-	`ceiling`:          ceiling,
-	`negated`:          negated,
-	`degreesToRadians`: degreesToRadians,
-}
-
-var booleanMessages = map[string]interface{}{
-	`value`:           value,
-	`=`:               boolEqual,
-	`~=`:              boolNotEqual,
-	`ifTrue:`:         ifTrue,
-	`ifFalse:`:        ifFalse,
-	`ifTrue:ifFalse:`: ifTrueIfFalse,
-	`ifFalse:ifTrue:`: ifFalseIfTrue,
-	`and:`:            and,
-	`&`:               ampersand,
-	`or:`:             or,
-	`|`:               verticalBar,
-	`xor:`:            xor,
-	`not`:             not,
-}
-
-var blockMessages = map[string]interface{}{
-	`value`:  value,
-	`value:`: valueWith,
-}
-
-var arrayMessages = map[string]interface{}{
-	`at:`: ValueAt,
-	`+`:   arrPlus,
-	`-`:   arrMinus,
-	`*`:   arrMul,
-	`/`:   arrDiv,
-	`\\`:  arrMod,
-	`//`:  arrIntDiv,
-}
-
-func value(receiver SmalltalkObjectInterface) SmalltalkObjectInterface {
-	return receiver.Value()
-}
-
-func valueWith(receiver *SmalltalkBlock, arg SmalltalkObjectInterface) SmalltalkObjectInterface {
-	scope := new(Scope).Initialize()
-	scope.OuterScope = receiver.scope
-	scope.SetVar(receiver.block.arguments[0].GetName(), arg)
-	return receiver.block.body.Eval(scope)
+var numberMessages = map[string]Method{
+	`value`:            unaryMethodE(value),
+	`=`:                binaryMethod(equal),
+	`~=`:               binaryMethod(notEqual),
+	`>`:                binaryMethod(greater),
+	`>=`:               binaryMethod(greaterEqual),
+	`<`:                binaryMethod(lesser),
+	`<=`:               binaryMethod(lesserEqual),
+	`+`:                binaryMethod(plus),
+	`-`:                binaryMethod(minus),
+	`*`:                binaryMethod(mul),
+	`/`:                zeroDivideChecked(`/`, div),
+	`\\`:               zeroDivideChecked(`\\`, mod),
+	`//`:               zeroDivideChecked(`//`, intDiv),
+	`rem:`:             zeroDivideChecked(`rem:`, rem),
+	`max:`:             binaryMethod(max),
+	`min:`:             binaryMethod(min),
+	`abs`:              unaryMethod(abs),
+	`sqrt`:             domainChecked(`sqrt`, sqrt),
+	`sqr`:              unaryMethod(sqr),
+	`sin`:              unaryMethod(sin),
+	`cos`:              unaryMethod(cos),
+	`tan`:              unaryMethod(tan),
+	`arcSin`:           domainChecked(`arcSin`, arcSin),
+	`arcCos`:           domainChecked(`arcCos`, arcCos),
+	`arcTan`:           unaryMethod(arcTan),
+	`rounded`:          unaryMethod(rounded),
+	`truncated`:        unaryMethod(truncated),
+	`fractionPart`:     unaryMethod(fractionPart),
+	`floor`:            unaryMethod(floor),
+	`ceiling`:          unaryMethod(ceiling),
+	`negated`:          unaryMethod(negated),
+	`degreesToRadians`: unaryMethod(degreesToRadians),
+	`to:do:`:           ternaryMethodE(toDo),
+	`to:by:do:`:        quaternaryMethodE(toByDo),
+	`timesRepeat:`:     binaryMethodE(timesRepeat),
+	`between:and:`:     ternaryMethod(betweenAnd),
+	`to:`:              binaryMethod(to),
+	`to:by:`:           ternaryMethodE(toBy),
+	`isInteger`:        unaryMethod(isInteger),
+	`isFloat`:          unaryMethod(isFloat),
+	`isNaN`:            unaryMethod(isNaN),
+	`isInfinite`:       unaryMethod(isInfinite),
+	`isFinite`:         unaryMethod(isFinite),
+	`asFloat`:          unaryMethod(asFloat),
+	`asInteger`:        unaryMethod(asInteger),
+	`gcd:`:             binaryMethodE(gcd),
+	`lcm:`:             binaryMethodE(lcm),
+	`factorial`:        unaryMethodE(factorial),
+	`bitAnd:`:          binaryMethodE(bitAnd),
+	`bitOr:`:           binaryMethodE(bitOr),
+	`bitShift:`:        binaryMethodE(bitShift),
+	`printString`:      unaryMethod(numberPrintString),
+	`printString:`:     binaryMethodE(numberPrintStringRadix),
+	`asCharacter`:      unaryMethodE(numberAsCharacter),
+	`numerator`:        unaryMethodE(numerator),
+	`denominator`:      unaryMethodE(denominator),
+	`isFraction`:       unaryMethod(isFraction),
+	`isScaledDecimal`:  unaryMethod(isScaledDecimal),
+	`asScaledDecimal:`: binaryMethodE(asScaledDecimal),
+	`roundTo:`:         binaryMethod(roundTo),
+}
+
+var booleanMessages = map[string]Method{
+	`value`:           unaryMethodE(value),
+	`=`:               binaryMethod(boolEqual),
+	`~=`:              binaryMethod(boolNotEqual),
+	`ifTrue:`:         binaryMethodE(ifTrue),
+	`ifFalse:`:        binaryMethodE(ifFalse),
+	`ifTrue:ifFalse:`: ternaryMethodE(ifTrueIfFalse),
+	`ifFalse:ifTrue:`: ternaryMethodE(ifFalseIfTrue),
+	`and:`:            binaryMethodE(and),
+	`and:and:`:        ternaryMethodE(andAnd),
+	`&`:               binaryMethodE(ampersand),
+	`or:`:             binaryMethodE(or),
+	`or:or:`:          ternaryMethodE(orOr),
+	`|`:               binaryMethodE(verticalBar),
+	`xor:`:            binaryMethodE(xor),
+	`eqv:`:            binaryMethodE(eqv),
+	`not`:             unaryMethod(not),
+	`printString`:     unaryMethod(booleanPrintString),
+	`asBit`:           unaryMethod(asBit),
+}
+
+var stringMessages = map[string]Method{
+	`value`:              unaryMethodE(value),
+	`,`:                  binaryMethod(concatenate),
+	`=`:                  binaryMethod(stringEqual),
+	`~=`:                 binaryMethod(stringNotEqual),
+	`<`:                  binaryMethod(stringLesser),
+	`<=`:                 binaryMethod(stringLesserEqual),
+	`>`:                  binaryMethod(stringGreater),
+	`>=`:                 binaryMethod(stringGreaterEqual),
+	`size`:               unaryMethod(stringSize),
+	`isEmpty`:            unaryMethod(stringIsEmpty),
+	`notEmpty`:           unaryMethod(stringNotEmpty),
+	`at:`:                binaryMethodE(stringAt),
+	`copyFrom:to:`:       ternaryMethodE(copyFromTo),
+	`indexOf:`:           binaryMethod(stringIndexOf),
+	`includesSubstring:`: binaryMethod(includesSubstring),
+	`beginsWith:`:        binaryMethod(beginsWith),
+	`endsWith:`:          binaryMethod(endsWith),
+	`asUppercase`:        unaryMethod(asUppercase),
+	`asLowercase`:        unaryMethod(asLowercase),
+	`trimBoth`:           unaryMethod(trimBoth),
+	`reversed`:           unaryMethod(stringReversed),
+	`asNumber`:           unaryMethod(asNumber),
+	`replaceAll:with:`:   ternaryMethodE(replaceAllWith),
+	`substrings`:         unaryMethod(substrings),
+	`substrings:`:        binaryMethod(substringsWith),
+	`format:`:            binaryMethodE(format),
+	`asSymbol`:           unaryMethod(stringAsSymbol),
+	`asString`:           unaryMethod(stringAsString),
+}
+
+var undefinedMessages = map[string]Method{
+	`value`:           unaryMethodE(value),
+	`=`:               binaryMethod(undefinedEqual),
+	`~=`:              binaryMethod(undefinedNotEqual),
+	`isNil`:           unaryMethod(isNil),
+	`notNil`:          unaryMethod(notNil),
+	`ifNil:`:          binaryMethodE(undefinedIfNil),
+	`ifNotNil:`:       binaryMethodE(undefinedIfNotNil),
+	`ifNil:ifNotNil:`: ternaryMethodE(undefinedIfNilIfNotNil),
+	`ifNotNil:ifNil:`: ternaryMethodE(undefinedIfNotNilIfNil),
+	`printString`:     unaryMethod(undefinedPrintString),
+}
+
+var symbolMessages = map[string]Method{
+	`value`:        unaryMethodE(value),
+	`=`:            binaryMethod(identical),
+	`~=`:           binaryMethod(notIdentical),
+	`size`:         unaryMethod(symbolSize),
+	`numArgs`:      unaryMethod(symbolNumArgs),
+	`asString`:     unaryMethod(symbolAsString),
+	`asSymbol`:     unaryMethod(symbolAsSymbol),
+	`printString`:  unaryMethod(symbolPrintString),
+	`value:`:       binaryMethodE(symbolValueWith),
+	`value:value:`: ternaryMethodE(symbolValueWithWith),
+}
+
+func init() {
+	// respondsTo: and perform: look up methods in the message tables, so they are added after they are initialized
+	objectMessages[`respondsTo:`] = binaryMethodE(respondsTo)
+	objectScopedMessages = map[string]scopedMethod{
+		`perform:`:                performWith,
+		`perform:with:`:           performWith,
+		`perform:with:with:`:      performWith,
+		`perform:with:with:with:`: performWith,
+		`perform:withArguments:`:  performWithArguments,
+	}
+	for selector, method := range objectScopedMessages {
+		objectMessages[selector] = withoutScope(method)
+	}
 }
 
-func equal(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() == arg.GetValue())
+// objectMessages are understood by objects of every type unless their own message table overrides them
+var objectMessages = map[string]Method{
+	`==`:              binaryMethod(identical),
+	`~~`:              binaryMethod(notIdentical),
+	`isNil`:           unaryMethod(objectIsNil),
+	`notNil`:          unaryMethod(objectNotNil),
+	`ifNil:`:          binaryMethod(ifNil),
+	`ifNotNil:`:       binaryMethodE(ifNotNil),
+	`ifNil:ifNotNil:`: ternaryMethodE(ifNilIfNotNil),
+	`ifNotNil:ifNil:`: ternaryMethodE(ifNotNilIfNil),
+	`yourself`:        unaryMethod(yourself),
+}
+
+var blockMessages = map[string]Method{
+	`value`:                    variadicMethodE(blockValue),
+	`value:`:                   variadicMethodE(blockValue),
+	`value:value:`:             variadicMethodE(blockValue),
+	`value:value:value:`:       variadicMethodE(blockValue),
+	`value:value:value:value:`: variadicMethodE(blockValue),
+	`valueWithArguments:`:      binaryMethodE(valueWithArguments),
+	`cull:`:                    variadicMethodE(cull),
+	`cull:cull:`:               variadicMethodE(cull),
+	`numArgs`:                  unaryMethod(numArgs),
+	`whileTrue:`:               binaryMethodE(whileTrue),
+	`whileFalse:`:              binaryMethodE(whileFalse),
+	`whileTrue`:                unaryMethodE(whileTrueUnary),
+	`whileFalse`:               unaryMethodE(whileFalseUnary),
+	`repeat`:                   unaryMethodE(repeat),
+	`ensure:`:                  binaryMethodE(ensure),
+	`ifCurtailed:`:             binaryMethodE(ifCurtailed),
+	`on:do:`:                   ternaryMethodE(onDo),
+}
+
+var arrayMessages = withCollectionMessages(map[string]Method{
+	`at:`:           binaryMethodE(ValueAt),
+	`at:put:`:       ternaryMethodE(arrayAtPut),
+	`=`:             binaryMethod(arrayEqual),
+	`~=`:            binaryMethod(arrayNotEqual),
+	`first`:         unaryMethodE(arrayFirst),
+	`last`:          unaryMethodE(arrayLast),
+	`copyWith:`:     binaryMethod(copyWith),
+	`,`:             binaryMethod(arrayConcatenate),
+	`reverse`:       unaryMethod(arrayReverse),
+	`reversed`:      unaryMethod(arrayReverse),
+	`sort`:          unaryMethodE(arraySort),
+	`sort:`:         binaryMethodE(arraySortWith),
+	`with:collect:`: ternaryMethodE(withCollect),
+	`+`:             elementwiseMethod(`+`),
+	`-`:             elementwiseMethod(`-`),
+	`*`:             elementwiseMethod(`*`),
+	`/`:             elementwiseMethod(`/`),
+	`\\`:            elementwiseMethod(`\\`),
+	`//`:            elementwiseMethod(`//`),
+	`<`:             elementwiseMethod(`<`),
+	`<=`:            elementwiseMethod(`<=`),
+	`>`:             elementwiseMethod(`>`),
+	`>=`:            elementwiseMethod(`>=`),
+	`dot:`:          binaryMethodE(dot),
+	`norm`:          unaryMethodE(norm),
+	`normalized`:    unaryMethodE(normalized),
+}, sequenceableMessages)
+
+func value(receiver SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return valueOf(receiver)
+}
+
+// Block methods
+func blockValue(receiver *SmalltalkBlock, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return receiver.ValueWithArguments(args)
+}
+
+func valueWithArguments(receiver *SmalltalkBlock, args *SmalltalkArray) (SmalltalkObjectInterface, error) {
+	return receiver.ValueWithArguments(args.array)
+}
+
+// cull evaluates the block with as many of args as it has arguments
+func cull(receiver *SmalltalkBlock, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if receiver.NumArgs() < len(args) {
+		args = args[:receiver.NumArgs()]
+	}
+	return receiver.ValueWithArguments(args)
 }
 
-func notEqual(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() != arg.GetValue())
+func numArgs(receiver *SmalltalkBlock) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(receiver.NumArgs()))
+}
+
+func whileTrue(receiver *SmalltalkBlock, body SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return loopWhile(receiver, true, body)
+}
+
+func whileFalse(receiver *SmalltalkBlock, body SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return loopWhile(receiver, false, body)
+}
+
+func whileTrueUnary(receiver *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	return loopWhile(receiver, true, nil)
+}
+
+func whileFalseUnary(receiver *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	return loopWhile(receiver, false, nil)
+}
+
+// loopWhile evaluates body while the receiver evaluates to expected and answers nil
+func loopWhile(receiver *SmalltalkBlock, expected bool, body SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	for {
+		condition, err := receiver.ValueWithArguments(nil)
+		if err != nil {
+			return nil, err
+		}
+		boolean, ok := condition.(*SmalltalkBoolean)
+		if !ok {
+			return nil, &MustBeBooleanError{Actual: condition.TypeOf()}
+		}
+		if boolean.GetValue() != expected {
+			return NewSmalltalkUndefinedObject(), nil
+		}
+		if body != nil {
+			_, err = valueOf(body)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// repeat evaluates the receiver until it returns with ^ or fails
+func repeat(receiver *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	for {
+		_, err := receiver.ValueWithArguments(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ensure evaluates ensureBlock after the receiver even if the receiver fails or returns with ^
+func ensure(receiver *SmalltalkBlock, ensureBlock SmalltalkObjectInterface) (result SmalltalkObjectInterface, err error) {
+	defer func() {
+		_, ensureErr := valueOf(ensureBlock)
+		if ensureErr != nil {
+			result, err = nil, joinErrors(err, ensureErr)
+		}
+	}()
+	return receiver.ValueWithArguments(nil)
+}
+
+// ifCurtailed evaluates curtailBlock only if the receiver fails or returns with ^
+func ifCurtailed(receiver *SmalltalkBlock, curtailBlock SmalltalkObjectInterface) (result SmalltalkObjectInterface, err error) {
+	completed := false
+	defer func() {
+		if !completed {
+			if _, curtailErr := valueOf(curtailBlock); curtailErr != nil {
+				result, err = nil, joinErrors(err, curtailErr)
+			}
+		}
+	}()
+	result, err = receiver.ValueWithArguments(nil)
+	completed = err == nil
+	return result, err
+}
+
+// joinErrors answers the error of an unwinding block which failed with err and whose cleanup block failed with
+// cleanupErr. The cleanup error replaces ^ and the unwinding of handlers, because they can not complete anymore.
+func joinErrors(err error, cleanupErr error) error {
+	switch err.(type) {
+	case nil, *nonLocalReturn, *handlerReturn, *handlerRetry, *handlerResume:
+		return cleanupErr
+	}
+	return &JoinedError{Errors: []error{unhandledError(err), unhandledError(cleanupErr)}}
+}
+
+func equal(receiver *SmalltalkNumber, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	number, ok := arg.(*SmalltalkNumber)
+	if !ok {
+		return NewSmalltalkBoolean(false)
+	}
+	order, ok := compare(receiver, number)
+	return NewSmalltalkBoolean(ok && order == 0)
+}
+
+func notEqual(receiver *SmalltalkNumber, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return not(equal(receiver, arg))
 }
 
 func greater(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() > arg.GetValue())
+	order, ok := compare(receiver, arg)
+	return NewSmalltalkBoolean(ok && order > 0)
 }
 
 func greaterEqual(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() >= arg.GetValue())
+	order, ok := compare(receiver, arg)
+	return NewSmalltalkBoolean(ok && order >= 0)
 }
 
 func lesser(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() < arg.GetValue())
+	order, ok := compare(receiver, arg)
+	return NewSmalltalkBoolean(ok && order < 0)
 }
 
 func lesserEqual(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() <= arg.GetValue())
+	order, ok := compare(receiver, arg)
+	return NewSmalltalkBoolean(ok && order <= 0)
 }
 
 func plus(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(receiver.GetValue() + arg.GetValue())
+	return arithmetic(receiver, arg, addInt64,
+		func(a *big.Int, b *big.Int) *big.Int { return new(big.Int).Add(a, b) }, addRat,
+		func(a float64, b float64) float64 { return a + b })
 }
 
 func minus(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(receiver.GetValue() - arg.GetValue())
+	return arithmetic(receiver, arg, subInt64,
+		func(a *big.Int, b *big.Int) *big.Int { return new(big.Int).Sub(a, b) }, subRat,
+		func(a float64, b float64) float64 { return a - b })
 }
 
 func mul(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(receiver.GetValue() * arg.GetValue())
+	return arithmetic(receiver, arg, mulInt64,
+		func(a *big.Int, b *big.Int) *big.Int { return new(big.Int).Mul(a, b) }, mulRat,
+		func(a float64, b float64) float64 { return a * b })
 }
 
+// div answers an integer if integers are divided without a remainder and a fraction otherwise
 func div(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(receiver.GetValue() / arg.GetValue())
+	return arithmetic(receiver, arg, exactDivInt64, exactDivBig, quoRat,
+		func(a float64, b float64) float64 { return a / b })
 }
 
 func mod(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(float64(int64(receiver.GetValue()) % int64(arg.GetValue())))
+	return arithmetic(receiver, arg, floorModInt64, floorModBig, floorModRat, floorModFloat)
 }
 
 func intDiv(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(math.Floor(receiver.GetValue() / arg.GetValue()))
+	return truncated(arithmetic(receiver, arg, floorDivInt64, floorDivBig, floorDivRat,
+		func(a float64, b float64) float64 { return math.Floor(a / b) }))
 }
 
 func rem(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	quo := math.Trunc(receiver.value / arg.value)
-	return new(SmalltalkNumber).SetValue(receiver.value - (quo * arg.value))
+	return arithmetic(receiver, arg, remInt64, remBig, remRat,
+		func(a float64, b float64) float64 { return a - math.Trunc(a/b)*b })
 }
 
 func max(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	if receiver.value > arg.value {
+	if greater(receiver, arg).value {
 		return receiver
 	} else {
 		return arg
@@ -155,7 +431,7 @@ func max(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
 }
 
 func min(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
-	if receiver.value > arg.value {
+	if greater(receiver, arg).value {
 		return arg
 	} else {
 		return receiver
@@ -164,7 +440,7 @@ func min(receiver *SmalltalkNumber, arg *SmalltalkNumber) *SmalltalkNumber {
 
 func abs(receiver *SmalltalkNumber) *SmalltalkNumber {
 	if receiver.value < 0 {
-		return new(SmalltalkNumber).SetValue(receiver.value * -1)
+		return negated(receiver)
 	} else {
 		return receiver
 	}
@@ -175,7 +451,7 @@ func sqrt(receiver *SmalltalkNumber) *SmalltalkNumber {
 }
 
 func sqr(receiver *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(math.Pow(receiver.value, 2))
+	return mul(receiver, receiver)
 }
 
 func sin(receiver *SmalltalkNumber) *SmalltalkNumber {
@@ -203,27 +479,54 @@ func arcTan(receiver *SmalltalkNumber) *SmalltalkNumber {
 }
 
 func rounded(receiver *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(math.Round(receiver.value))
+	switch receiver.kind {
+	case floatNumber:
+		return floatToInteger(math.Round(receiver.value))
+	case fraction, scaledDecimal:
+		return NewSmalltalkLargeInteger(roundedOfRat(receiver.rational))
+	}
+	return receiver
 }
 
 func truncated(receiver *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(math.Trunc(receiver.value))
+	switch receiver.kind {
+	case floatNumber:
+		return floatToInteger(math.Trunc(receiver.value))
+	case fraction, scaledDecimal:
+		return NewSmalltalkLargeInteger(truncatedOfRat(receiver.rational))
+	}
+	return receiver
 }
 
 func floor(receiver *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(math.Floor(receiver.value))
+	switch receiver.kind {
+	case floatNumber:
+		return floatToInteger(math.Floor(receiver.value))
+	case fraction, scaledDecimal:
+		return NewSmalltalkLargeInteger(floorOfRat(receiver.rational))
+	}
+	return receiver
 }
 
 func ceiling(receiver *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(math.Ceil(receiver.value))
+	switch receiver.kind {
+	case floatNumber:
+		return floatToInteger(math.Ceil(receiver.value))
+	case fraction, scaledDecimal:
+		return negated(floor(negated(receiver)))
+	}
+	return receiver
 }
 
 func fractionPart(receiver *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(receiver.value - math.Trunc(receiver.value))
+	if receiver.kind == floatNumber {
+		return new(SmalltalkNumber).SetValue(receiver.value - math.Trunc(receiver.value))
+	}
+	return minus(receiver, truncated(receiver))
 }
 
 func negated(receiver *SmalltalkNumber) *SmalltalkNumber {
-	return new(SmalltalkNumber).SetValue(receiver.value * -1)
+	return minus(NewSmalltalkInteger(0), receiver)
 }
 
 func degreesToRadians(receiver *SmalltalkNumber) *SmalltalkNumber {
@@ -231,49 +534,111 @@ func degreesToRadians(receiver *SmalltalkNumber) *SmalltalkNumber {
 }
 
 //Boolean receiver messages section
-func boolEqual(receiver *SmalltalkBoolean, arg *SmalltalkBoolean) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() == arg.GetValue())
+func boolEqual(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	boolean, ok := arg.(*SmalltalkBoolean)
+	return new(SmalltalkBoolean).SetValue(ok && receiver.GetValue() == boolean.GetValue())
+}
+
+func boolNotEqual(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return not(boolEqual(receiver, arg))
 }
 
-func boolNotEqual(receiver *SmalltalkBoolean, arg *SmalltalkBoolean) *SmalltalkBoolean {
-	return new(SmalltalkBoolean).SetValue(receiver.GetValue() != arg.GetValue())
+// booleanValueOf answers the value of a boolean message argument, which is a boolean or a block answering a boolean
+func booleanValueOf(arg SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	result, err := valueOf(arg)
+	if err != nil {
+		return nil, err
+	}
+	boolean, ok := result.(*SmalltalkBoolean)
+	if !ok {
+		return nil, &MustBeBooleanError{Actual: result.TypeOf()}
+	}
+	return boolean, nil
 }
 
-func and(receiver *SmalltalkBoolean, arg *SmalltalkBlock) *SmalltalkBoolean {
+func and(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
 	if receiver.GetValue() {
-		return arg.Value().(*SmalltalkBoolean)
+		return booleanValueOf(arg)
 	} else {
-		return receiver
+		return receiver, nil
+	}
+}
+
+func andAnd(receiver *SmalltalkBoolean, first SmalltalkObjectInterface, second SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	result, err := and(receiver, first)
+	if err != nil {
+		return nil, err
 	}
+	return and(result, second)
 }
 
-func ampersand(receiver *SmalltalkBoolean, arg *SmalltalkBoolean) *SmalltalkBoolean {
+func ampersand(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	boolean, ok := arg.(*SmalltalkBoolean)
+	if !ok {
+		return nil, &MustBeBooleanError{Actual: arg.TypeOf()}
+	}
 	if receiver.GetValue() {
-		return arg
+		return boolean, nil
 	} else {
-		return receiver
+		return receiver, nil
 	}
 }
 
-func or(receiver *SmalltalkBoolean, arg *SmalltalkBlock) *SmalltalkBoolean {
+func or(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
 	if receiver.GetValue() {
-		return receiver
+		return receiver, nil
 	} else {
-		return arg.Value().(*SmalltalkBoolean)
+		return booleanValueOf(arg)
 	}
 }
 
-func verticalBar(receiver *SmalltalkBoolean, arg *SmalltalkBoolean) *SmalltalkBoolean {
+func orOr(receiver *SmalltalkBoolean, first SmalltalkObjectInterface, second SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	result, err := or(receiver, first)
+	if err != nil {
+		return nil, err
+	}
+	return or(result, second)
+}
+
+func verticalBar(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	boolean, ok := arg.(*SmalltalkBoolean)
+	if !ok {
+		return nil, &MustBeBooleanError{Actual: arg.TypeOf()}
+	}
 	if receiver.GetValue() {
-		return receiver
+		return receiver, nil
 	} else {
-		return arg
+		return boolean, nil
 	}
 }
 
-func xor(receiver *SmalltalkBoolean, arg *SmalltalkBoolean) *SmalltalkBoolean {
-	xor := !(receiver.GetValue() == arg.GetValue())
-	return new(SmalltalkBoolean).SetValue(xor)
+func xor(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	boolean, err := booleanValueOf(arg)
+	if err != nil {
+		return nil, err
+	}
+	return NewSmalltalkBoolean(receiver.GetValue() != boolean.GetValue()), nil
+}
+
+// eqv answers true when the receiver and the argument are both true or both false
+func eqv(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	result, err := xor(receiver, arg)
+	if err != nil {
+		return nil, err
+	}
+	return not(result), nil
+}
+
+func booleanPrintString(receiver *SmalltalkBoolean) *SmalltalkString {
+	return NewSmalltalkString(strconv.FormatBool(receiver.GetValue()))
+}
+
+// asBit answers 1 for true and 0 for false
+func asBit(receiver *SmalltalkBoolean) *SmalltalkNumber {
+	if receiver.GetValue() {
+		return NewSmalltalkInteger(1)
+	}
+	return NewSmalltalkInteger(0)
 }
 
 func not(receiver *SmalltalkBoolean) *SmalltalkBoolean {
@@ -284,128 +649,777 @@ func not(receiver *SmalltalkBoolean) *SmalltalkBoolean {
 	}
 }
 
-func ifTrue(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) SmalltalkObjectInterface {
+func ifTrue(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
 	if receiver.GetValue() {
-		return arg.Value()
+		return valueOf(arg)
 	} else {
-		return NewSmalltalkUndefinedObject()
+		return NewSmalltalkUndefinedObject(), nil
 	}
 }
 
-func ifFalse(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) SmalltalkObjectInterface {
+func ifFalse(receiver *SmalltalkBoolean, arg SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
 	if receiver.GetValue() {
-		return NewSmalltalkUndefinedObject()
+		return NewSmalltalkUndefinedObject(), nil
 	} else {
-		return arg.Value()
+		return valueOf(arg)
 	}
 }
 
-func ifTrueIfFalse(receiver *SmalltalkBoolean, argTrue SmalltalkObjectInterface, argFalse SmalltalkObjectInterface) SmalltalkObjectInterface {
+func ifTrueIfFalse(receiver *SmalltalkBoolean, argTrue SmalltalkObjectInterface, argFalse SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
 	if receiver.GetValue() {
-		return argTrue.Value()
+		return valueOf(argTrue)
 	} else {
-		return argFalse.Value()
+		return valueOf(argFalse)
 	}
 }
 
-func ifFalseIfTrue(receiver *SmalltalkBoolean, argFalse SmalltalkObjectInterface, argTrue SmalltalkObjectInterface) SmalltalkObjectInterface {
+func ifFalseIfTrue(receiver *SmalltalkBoolean, argFalse SmalltalkObjectInterface, argTrue SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
 	if receiver.GetValue() {
-		return argTrue.Value()
+		return valueOf(argTrue)
 	} else {
-		return argFalse.Value()
+		return valueOf(argFalse)
 	}
 }
 
-// Array methods
-func ValueAt(receiver *SmalltalkArray, index *SmalltalkNumber) SmalltalkObjectInterface {
-	return receiver.array[int64(index.value)-1]
+// UndefinedObject methods
+func undefinedEqual(receiver *SmalltalkUndefinedObject, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(arg.TypeOf() == UNDEFINED_OBJ)
 }
 
-func arrPlus(receiver *SmalltalkArray, number *SmalltalkNumber) SmalltalkObjectInterface {
-	result := new(SmalltalkArray)
-	for _, each := range receiver.array {
-		result.array = append(result.array, plus(each.(*SmalltalkNumber), number))
+func undefinedNotEqual(receiver *SmalltalkUndefinedObject, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(arg.TypeOf() != UNDEFINED_OBJ)
+}
+
+func isNil(receiver *SmalltalkUndefinedObject) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(true)
+}
+
+func notNil(receiver *SmalltalkUndefinedObject) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(false)
+}
+
+func undefinedIfNil(receiver *SmalltalkUndefinedObject, nilBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return valueOf(nilBlock)
+}
+
+func undefinedIfNotNil(receiver *SmalltalkUndefinedObject, notNilBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return receiver, nil
+}
+
+func undefinedIfNilIfNotNil(receiver *SmalltalkUndefinedObject, nilBlock SmalltalkObjectInterface, notNilBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return valueOf(nilBlock)
+}
+
+func undefinedIfNotNilIfNil(receiver *SmalltalkUndefinedObject, notNilBlock SmalltalkObjectInterface, nilBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return valueOf(nilBlock)
+}
+
+func undefinedPrintString(receiver *SmalltalkUndefinedObject) *SmalltalkString {
+	return NewSmalltalkString("nil")
+}
+
+// yourself answers the receiver, so a cascade like c add: 1; yourself answers the collection
+func yourself(receiver SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return receiver
+}
+
+// Methods of all objects which are not nil
+func objectIsNil(receiver SmalltalkObjectInterface) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(false)
+}
+
+func objectNotNil(receiver SmalltalkObjectInterface) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(true)
+}
+
+// identical answers whether receiver and arg are the same object. Numbers, booleans and nil are identical
+// when they are equal, like immediate objects of Smalltalk.
+func identical(receiver SmalltalkObjectInterface, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	switch typedReceiver := receiver.(type) {
+	case *SmalltalkNumber:
+		number, ok := arg.(*SmalltalkNumber)
+		return NewSmalltalkBoolean(ok && typedReceiver.kind == number.kind && equal(typedReceiver, number).value)
+	case *SmalltalkBoolean:
+		boolean, ok := arg.(*SmalltalkBoolean)
+		return NewSmalltalkBoolean(ok && typedReceiver.value == boolean.value)
+	case *SmalltalkCharacter:
+		character, ok := arg.(*SmalltalkCharacter)
+		return NewSmalltalkBoolean(ok && typedReceiver.value == character.value)
+	case *SmalltalkUndefinedObject:
+		return NewSmalltalkBoolean(arg.TypeOf() == UNDEFINED_OBJ)
 	}
+	return NewSmalltalkBoolean(receiver == arg)
+}
 
-	return result
+func notIdentical(receiver SmalltalkObjectInterface, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return not(identical(receiver, arg))
 }
 
-func arrMinus(receiver *SmalltalkArray, number *SmalltalkNumber) SmalltalkObjectInterface {
-	result := new(SmalltalkArray)
-	for _, each := range receiver.array {
-		result.array = append(result.array, minus(each.(*SmalltalkNumber), number))
+// performWith sends the selector from the first argument to the receiver with the rest of the arguments
+// withoutScope answers a method which sends method without a scope, like Send does
+func withoutScope(method scopedMethod) Method {
+	return func(receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+		return method(nil, receiver, args)
 	}
+}
 
-	return result
+func performWith(scope *Scope, receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if len(args) == 0 {
+		return nil, &WrongArgumentCountError{Expected: 1, Actual: 0}
+	}
+	return performIn(scope, receiver, args[0], args[1:])
 }
 
-func arrMul(receiver *SmalltalkArray, number *SmalltalkNumber) SmalltalkObjectInterface {
-	result := new(SmalltalkArray)
-	for _, each := range receiver.array {
-		result.array = append(result.array, mul(each.(*SmalltalkNumber), number))
+func performWithArguments(scope *Scope, receiver SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	err := checkArgumentsCount(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	arguments, ok := args[1].(*SmalltalkArray)
+	if !ok {
+		return nil, &TypeMismatchError{Expected: ARRAY_OBJ, Actual: args[1].TypeOf()}
 	}
+	return performIn(scope, receiver, args[0], arguments.array)
+}
 
-	return result
+// performIn sends the message with scope, so perform: defines classes like a message expression does
+func performIn(scope *Scope, receiver SmalltalkObjectInterface, selector SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	name, err := performedSelector(selector, args)
+	if err != nil {
+		return nil, err
+	}
+	return sendIn(scope, receiver, name, args)
 }
 
-func arrDiv(receiver *SmalltalkArray, number *SmalltalkNumber) SmalltalkObjectInterface {
-	result := new(SmalltalkArray)
-	for _, each := range receiver.array {
-		result.array = append(result.array, div(each.(*SmalltalkNumber), number))
+func perform(receiver SmalltalkObjectInterface, selector SmalltalkObjectInterface, args []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	name, err := performedSelector(selector, args)
+	if err != nil {
+		return nil, err
 	}
+	return Send(receiver, name, args)
+}
 
-	return result
+// performedSelector answers the name of selector if it takes as many arguments as args has
+func performedSelector(selector SmalltalkObjectInterface, args []SmalltalkObjectInterface) (string, error) {
+	name, err := selectorName(selector)
+	if err != nil {
+		return "", err
+	}
+	if SelectorArity(name) != len(args) {
+		return "", &WrongArgumentCountError{Selector: name, Expected: SelectorArity(name), Actual: len(args)}
+	}
+	return name, nil
 }
 
-func arrMod(receiver *SmalltalkArray, number *SmalltalkNumber) SmalltalkObjectInterface {
-	result := new(SmalltalkArray)
-	for _, each := range receiver.array {
-		result.array = append(result.array, mod(each.(*SmalltalkNumber), number))
+func respondsTo(receiver SmalltalkObjectInterface, selector SmalltalkObjectInterface) (*SmalltalkBoolean, error) {
+	name, err := selectorName(selector)
+	if err != nil {
+		return nil, err
 	}
+	if responding, ok := receiver.(respondingObject); ok {
+		return NewSmalltalkBoolean(responding.respondsTo(name)), nil
+	}
+	methodTablesLock.RLock()
+	table := methodTables[receiver.TypeOf()]
+	methodTablesLock.RUnlock()
+	return NewSmalltalkBoolean(lookupMethod(table, name) != nil), nil
+}
+
+// selectorName answers the name of a selector given as a symbol or a string
+func selectorName(selector SmalltalkObjectInterface) (string, error) {
+	switch typedSelector := selector.(type) {
+	case *SmalltalkSymbol:
+		return typedSelector.name, nil
+	case *SmalltalkString:
+		return typedSelector.value, nil
+	}
+	return "", &TypeMismatchError{Expected: SYMBOL_OBJ, Actual: selector.TypeOf()}
+}
 
-	return result
+func ifNil(receiver SmalltalkObjectInterface, nilBlock SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return receiver
 }
 
-func arrIntDiv(receiver *SmalltalkArray, number *SmalltalkNumber) SmalltalkObjectInterface {
-	result := new(SmalltalkArray)
-	for _, each := range receiver.array {
-		result.array = append(result.array, intDiv(each.(*SmalltalkNumber), number))
+func ifNotNil(receiver SmalltalkObjectInterface, notNilBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return cullValue(notNilBlock, receiver)
+}
+
+func ifNilIfNotNil(receiver SmalltalkObjectInterface, nilBlock SmalltalkObjectInterface, notNilBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return cullValue(notNilBlock, receiver)
+}
+
+func ifNotNilIfNil(receiver SmalltalkObjectInterface, notNilBlock SmalltalkObjectInterface, nilBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return cullValue(notNilBlock, receiver)
+}
+
+// Symbol methods
+func symbolSize(receiver *SmalltalkSymbol) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(len([]rune(receiver.name))))
+}
+
+func symbolNumArgs(receiver *SmalltalkSymbol) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(SelectorArity(receiver.name)))
+}
+
+func symbolAsString(receiver *SmalltalkSymbol) *SmalltalkString {
+	return NewSmalltalkString(receiver.name)
+}
+
+func symbolAsSymbol(receiver *SmalltalkSymbol) *SmalltalkSymbol {
+	return receiver
+}
+
+func symbolPrintString(receiver *SmalltalkSymbol) *SmalltalkString {
+	return NewSmalltalkString("#" + receiver.name)
+}
+
+// symbolValueWith sends the receiver as a unary message to arg, so #abs value: -3 answers 3
+func symbolValueWith(receiver *SmalltalkSymbol, arg SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return perform(arg, receiver, nil)
+}
+
+func symbolValueWithWith(receiver *SmalltalkSymbol, arg SmalltalkObjectInterface, secondArg SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return perform(arg, receiver, []SmalltalkObjectInterface{secondArg})
+}
+
+// String methods. Strings are indexed by characters, not by bytes, and every method answers a new string.
+func concatenate(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkString {
+	return NewSmalltalkString(receiver.value + arg.value)
+}
+
+func stringEqual(receiver *SmalltalkString, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	argString, ok := arg.(*SmalltalkString)
+	return NewSmalltalkBoolean(ok && receiver.value == argString.value)
+}
+
+func stringNotEqual(receiver *SmalltalkString, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return not(stringEqual(receiver, arg))
+}
+
+func stringLesser(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value < arg.value)
+}
+
+func stringLesserEqual(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value <= arg.value)
+}
+
+func stringGreater(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value > arg.value)
+}
+
+func stringGreaterEqual(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value >= arg.value)
+}
+
+func stringSize(receiver *SmalltalkString) *SmalltalkNumber {
+	return NewSmalltalkInteger(int64(len([]rune(receiver.value))))
+}
+
+func stringIsEmpty(receiver *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value == "")
+}
+
+func stringNotEmpty(receiver *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(receiver.value != "")
+}
+
+// stringAt answers the character at index
+func stringAt(receiver *SmalltalkString, index *SmalltalkNumber) (*SmalltalkCharacter, error) {
+	characters := []rune(receiver.value)
+	i, err := offsetOf(index, len(characters))
+	if err != nil {
+		return nil, err
+	}
+	return NewSmalltalkCharacter(characters[i]), nil
+}
+
+func copyFromTo(receiver *SmalltalkString, start *SmalltalkNumber, stop *SmalltalkNumber) (*SmalltalkString, error) {
+	characters := []rune(receiver.value)
+	// an empty copy is allowed from anywhere including the position after the last character
+	if stop.value == start.value-1 && start.value >= 1 && int(start.value) <= len(characters)+1 {
+		return NewSmalltalkString(""), nil
+	}
+	from, err := offsetOf(start, len(characters))
+	if err != nil {
+		return nil, err
+	}
+	to, err := offsetOf(stop, len(characters))
+	if err != nil {
+		return nil, err
+	}
+	if to < from {
+		return NewSmalltalkString(""), nil
+	}
+	return NewSmalltalkString(string(characters[from : to+1])), nil
+}
+
+// stringIndexOf answers the index of the first occurrence of arg in the receiver or 0 if there is no such occurrence
+func stringIndexOf(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkNumber {
+	byteIndex := strings.Index(receiver.value, arg.value)
+	if byteIndex < 0 {
+		return NewSmalltalkInteger(0)
 	}
+	return NewSmalltalkInteger(int64(len([]rune(receiver.value[:byteIndex])) + 1))
+}
+
+func includesSubstring(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(strings.Contains(receiver.value, arg.value))
+}
+
+func beginsWith(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(strings.HasPrefix(receiver.value, arg.value))
+}
+
+func endsWith(receiver *SmalltalkString, arg *SmalltalkString) *SmalltalkBoolean {
+	return NewSmalltalkBoolean(strings.HasSuffix(receiver.value, arg.value))
+}
 
+func asUppercase(receiver *SmalltalkString) *SmalltalkString {
+	return NewSmalltalkString(strings.ToUpper(receiver.value))
+}
+
+func asLowercase(receiver *SmalltalkString) *SmalltalkString {
+	return NewSmalltalkString(strings.ToLower(receiver.value))
+}
+
+func trimBoth(receiver *SmalltalkString) *SmalltalkString {
+	return NewSmalltalkString(strings.TrimSpace(receiver.value))
+}
+
+func stringReversed(receiver *SmalltalkString) *SmalltalkString {
+	characters := []rune(receiver.value)
+	for i, j := 0, len(characters)-1; i < j; i, j = i+1, j-1 {
+		characters[i], characters[j] = characters[j], characters[i]
+	}
+	return NewSmalltalkString(string(characters))
+}
+
+// asNumber answers nil if the receiver is not a number
+func asNumber(receiver *SmalltalkString) SmalltalkObjectInterface {
+	number, err := parseNumber(strings.TrimSpace(receiver.value))
+	if err != nil {
+		return NewSmalltalkUndefinedObject()
+	}
+	return number
+}
+
+func replaceAllWith(receiver *SmalltalkString, old *SmalltalkString, replacement *SmalltalkString) (*SmalltalkString, error) {
+	if old.value == "" {
+		return nil, errors.New("replaceAll:with: can not replace an empty string")
+	}
+	return NewSmalltalkString(strings.ReplaceAll(receiver.value, old.value, replacement.value)), nil
+}
+
+// substrings answers an array of the parts of the receiver separated by white space
+func substrings(receiver *SmalltalkString) *SmalltalkArray {
+	return stringsToArray(strings.FieldsFunc(receiver.value, unicode.IsSpace))
+}
+
+// substringsWith answers an array of the parts of the receiver separated by any character of separators
+func substringsWith(receiver *SmalltalkString, separators *SmalltalkString) *SmalltalkArray {
+	return stringsToArray(strings.FieldsFunc(receiver.value, func(character rune) bool {
+		return strings.ContainsRune(separators.value, character)
+	}))
+}
+
+func stringAsSymbol(receiver *SmalltalkString) *SmalltalkSymbol {
+	return NewSmalltalkSymbol(receiver.value)
+}
+
+func stringAsString(receiver *SmalltalkString) *SmalltalkString {
+	return receiver
+}
+
+func stringsToArray(parts []string) *SmalltalkArray {
+	result := new(SmalltalkArray)
+	for _, each := range parts {
+		result.array = append(result.array, NewSmalltalkString(each))
+	}
 	return result
 }
 
-///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-func Call(receiver SmalltalkObjectInterface, m map[string]interface{}, name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
-	var receiverAndArgs []SmalltalkObjectInterface
-	if receiver.TypeOf() == DEFERRED {
-		receiverAndArgs = append(receiverAndArgs, receiver.Value())
-	} else {
-		receiverAndArgs = append(receiverAndArgs, receiver)
+// format answers a copy of the receiver where every {n} is replaced with the n-th element of args, e.g.
+// '{1} is {2}' format: #('speed' 42) answers 'speed is 42'. \{ is a literal brace.
+func format(receiver *SmalltalkString, args *SmalltalkArray) (*SmalltalkString, error) {
+	var result strings.Builder
+	text := receiver.value
+	for len(text) > 0 {
+		switch {
+		case strings.HasPrefix(text, `\{`):
+			result.WriteByte('{')
+			text = text[2:]
+		case text[0] == '{' && strings.IndexByte(text, '}') > 0:
+			end := strings.IndexByte(text, '}')
+			index, err := strconv.Atoi(text[1:end])
+			if err != nil {
+				result.WriteString(text[:end+1])
+			} else {
+				if index < 1 || index > len(args.array) {
+					return nil, &SubscriptOutOfBoundsError{Index: int64(index), Size: len(args.array)}
+				}
+				result.WriteString(displayString(args.array[index-1]))
+			}
+			text = text[end+1:]
+		default:
+			result.WriteByte(text[0])
+			text = text[1:]
+		}
+	}
+	return NewSmalltalkString(result.String()), nil
+}
+
+// offsetOf answers the zero based offset of the smalltalk index in a collection of size elements
+func offsetOf(index *SmalltalkNumber, size int) (int, error) {
+	if !index.IsInteger() && index.value != math.Trunc(index.value) {
+		return 0, &SubscriptOutOfBoundsError{Size: size, Subscript: index.PrintString()}
+	}
+	if index.value < 1 || index.value > float64(size) {
+		return 0, &SubscriptOutOfBoundsError{Index: int64(index.value), Size: size}
+	}
+	return int(index.value) - 1, nil
+}
+
+// displayString answers the text of the object the way it is shown to a user, so strings have no quotes
+func displayString(object SmalltalkObjectInterface) string {
+	return displayStringIn(object, nil)
+}
+
+// displayStringIn answers the display string of object which is an element of the collections in visited.
+// A collection inside of itself is shown as ... like in Pharo.
+func displayStringIn(object SmalltalkObjectInterface, visited visitedObjects) string {
+	switch object.(type) {
+	case *SmalltalkArray, *SmalltalkOrderedCollection, *SmalltalkSet, *SmalltalkBag, *SmalltalkDictionary:
+		var ok bool
+		visited, ok = enterCollection(visited, object)
+		if !ok {
+			return "..."
+		}
+		defer delete(visited, object)
 	}
-	for _, each := range params {
-		if each.TypeOf() == DEFERRED {
-			receiverAndArgs = append(receiverAndArgs, each.Value())
-		} else {
-			receiverAndArgs = append(receiverAndArgs, each)
+	switch typedObject := object.(type) {
+	case *SmalltalkString:
+		return typedObject.value
+	case *SmalltalkSymbol:
+		return typedObject.name
+	case *SmalltalkCharacter:
+		return string(typedObject.value)
+	case *SmalltalkNumber:
+		return typedObject.displayString()
+	case *SmalltalkBoolean:
+		return strconv.FormatBool(typedObject.value)
+	case *SmalltalkUndefinedObject:
+		return "nil"
+	case *SmalltalkArray:
+		elements := make([]string, len(typedObject.array))
+		for i, each := range typedObject.array {
+			elements[i] = displayStringIn(each, visited)
 		}
+		return "#(" + strings.Join(elements, " ") + ")"
+	case *SmalltalkByteArray:
+		return typedObject.printString()
+	case *SmalltalkOrderedCollection:
+		return typedObject.displayString(visited)
+	case *SmalltalkSet:
+		return typedObject.displayString(visited)
+	case *SmalltalkBag:
+		return typedObject.displayString(visited)
+	case *SmalltalkDictionary:
+		return typedObject.displayString(visited)
+	case *SmalltalkClass:
+		return typedObject.GetName()
+	case *SmalltalkException:
+		return withArticle(typedObject.class.GetName())
+	case *SmalltalkInstance:
+		return typedObject.displayString()
+	case *SmalltalkContext:
+		return typedObject.displayString()
+	case *SmalltalkGoObject:
+		return typedObject.displayString()
+	default:
+		return "a " + object.TypeOf()
 	}
-	f, ok := m[name]
+}
+
+// subscriptOf answers the zero based offset of index like offsetOf does. An index which is not a number is out of bounds too.
+func subscriptOf(index SmalltalkObjectInterface, size int) (int, error) {
+	number, ok := index.(*SmalltalkNumber)
 	if !ok {
-		err := errors.New("does not understand: " + name)
+		return 0, &SubscriptOutOfBoundsError{Size: size, Subscript: displayString(index)}
+	}
+	return offsetOf(number, size)
+}
+
+// Array methods
+func ValueAt(receiver *SmalltalkArray, index SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	i, err := subscriptOf(index, len(receiver.array))
+	if err != nil {
+		return nil, err
+	}
+	return receiver.array[i], nil
+}
+
+func arrayAtPut(receiver *SmalltalkArray, index SmalltalkObjectInterface, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	i, err := subscriptOf(index, len(receiver.array))
+	if err != nil {
 		return nil, err
 	}
-	function := reflect.ValueOf(f)
-	if len(receiverAndArgs) != function.Type().NumIn() {
-		err := errors.New("wrong parameters length")
+	receiver.array[i] = element
+	return element, nil
+}
+
+func arrayFirst(receiver *SmalltalkArray) (SmalltalkObjectInterface, error) {
+	return ValueAt(receiver, NewSmalltalkInteger(1))
+}
+
+func arrayLast(receiver *SmalltalkArray) (SmalltalkObjectInterface, error) {
+	return ValueAt(receiver, NewSmalltalkInteger(int64(len(receiver.array))))
+}
+
+// copyWith answers a new array with element added after the elements of the receiver
+func copyWith(receiver *SmalltalkArray, element SmalltalkObjectInterface) *SmalltalkArray {
+	return NewSmalltalkArray(append(receiver.elements(), element))
+}
+
+func arrayConcatenate(receiver *SmalltalkArray, other collection) *SmalltalkArray {
+	return NewSmalltalkArray(append(receiver.elements(), other.elements()...))
+}
+
+func arrayEqual(receiver *SmalltalkArray, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	_, ok := arg.(*SmalltalkArray)
+	return NewSmalltalkBoolean(ok && objectsEqual(receiver, arg))
+}
+
+func arrayNotEqual(receiver *SmalltalkArray, arg SmalltalkObjectInterface) *SmalltalkBoolean {
+	return not(arrayEqual(receiver, arg))
+}
+
+func arrayReverse(receiver *SmalltalkArray) *SmalltalkArray {
+	elements := receiver.elements()
+	for i, j := 0, len(elements)-1; i < j; i, j = i+1, j-1 {
+		elements[i], elements[j] = elements[j], elements[i]
+	}
+	return NewSmalltalkArray(elements)
+}
+
+// arraySort sorts the receiver in place with <= and answers it
+func arraySort(receiver *SmalltalkArray) (SmalltalkObjectInterface, error) {
+	return arraySortWith(receiver, nil)
+}
+
+func arraySortWith(receiver *SmalltalkArray, sortBlock *SmalltalkBlock) (SmalltalkObjectInterface, error) {
+	sorted := receiver.elements()
+	err := sortElements(sorted, sortBlock)
+	if err != nil {
 		return nil, err
 	}
-	in := make([]reflect.Value, len(receiverAndArgs))
-	for k, param := range receiverAndArgs {
+	copy(receiver.array, sorted)
+	return receiver, nil
+}
+
+// withCollect answers an array of results of block for elements of the receiver and other with the same index
+func withCollect(receiver *SmalltalkArray, other collection, block *SmalltalkBlock) (*SmalltalkArray, error) {
+	otherElements := other.elements()
+	if len(otherElements) != len(receiver.array) {
+		return nil, sameSizeError(len(receiver.array), len(otherElements))
+	}
+	result := make([]SmalltalkObjectInterface, len(receiver.array))
+	for i, each := range receiver.array {
+		element, err := block.ValueWithArguments([]SmalltalkObjectInterface{each, otherElements[i]})
+		if err != nil {
+			return nil, err
+		}
+		result[i] = element
+	}
+	return NewSmalltalkArray(result), nil
+}
+
+// elementwiseMethod answers a method which sends selector to every element of an array with the argument.
+// The argument may be an array of the same size, then elements with the same index are combined.
+func elementwiseMethod(selector string) Method {
+	return binaryMethodE(func(receiver *SmalltalkArray, arg SmalltalkObjectInterface) (*SmalltalkArray, error) {
+		return elementwise(receiver, selector, arg)
+	})
+}
+
+func elementwise(receiver *SmalltalkArray, selector string, arg SmalltalkObjectInterface) (*SmalltalkArray, error) {
+	return elementwiseIn(receiver, selector, arg, nil)
+}
+
+// elementwiseIn combines receiver with arg like elementwise does. Receiver is an element of the arrays in visited.
+func elementwiseIn(receiver *SmalltalkArray, selector string, arg SmalltalkObjectInterface, visited visitedObjects) (*SmalltalkArray, error) {
+	visited, ok := enterCollection(visited, receiver)
+	if !ok {
+		return nil, errCyclicArray
+	}
+	defer delete(visited, receiver)
+	argArray, isArray := arg.(*SmalltalkArray)
+	if isArray && len(argArray.array) != len(receiver.array) {
+		return nil, sameSizeError(len(receiver.array), len(argArray.array))
+	}
+	result := make([]SmalltalkObjectInterface, len(receiver.array))
+	for i, each := range receiver.array {
+		operand := arg
+		if isArray {
+			operand = argArray.array[i]
+		}
+		element, err := broadcast(each, selector, operand, visited)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = element
+	}
+	return NewSmalltalkArray(result), nil
+}
+
+// errCyclicArray is answered when an array which contains itself is combined elementwise, because the result would be infinite
+var errCyclicArray = errors.New("arrays which contain themselves can not be combined elementwise")
+
+// broadcast combines a and b with selector. When only b is an array, a is combined with each of its elements,
+// so #(1 2) + #(#(1 2) 3) is #(#(2 3) 5).
+func broadcast(a SmalltalkObjectInterface, selector string, b SmalltalkObjectInterface, visited visitedObjects) (SmalltalkObjectInterface, error) {
+	if array, ok := a.(*SmalltalkArray); ok {
+		return elementwiseIn(array, selector, b, visited)
+	}
+	array, ok := b.(*SmalltalkArray)
+	if !ok {
+		return Send(a, selector, []SmalltalkObjectInterface{b})
+	}
+	visited, ok = enterCollection(visited, array)
+	if !ok {
+		return nil, errCyclicArray
+	}
+	defer delete(visited, array)
+	result := make([]SmalltalkObjectInterface, len(array.array))
+	for i, each := range array.array {
+		element, err := broadcast(a, selector, each, visited)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = element
+	}
+	return NewSmalltalkArray(result), nil
+}
+
+func sameSizeError(size int, otherSize int) error {
+	return errors.New("collections of sizes " + strconv.Itoa(size) + " and " + strconv.Itoa(otherSize) + " should have the same size")
+}
+
+// dot answers the sum of products of elements with the same index
+func dot(receiver *SmalltalkArray, other *SmalltalkArray) (SmalltalkObjectInterface, error) {
+	products, err := elementwise(receiver, `*`, other)
+	if err != nil {
+		return nil, err
+	}
+	if len(products.array) == 0 {
+		return NewSmalltalkInteger(0), nil
+	}
+	return collectionSum(products)
+}
+
+// norm answers the euclidean length of the array
+func norm(receiver *SmalltalkArray) (SmalltalkObjectInterface, error) {
+	square, err := dot(receiver, receiver)
+	if err != nil {
+		return nil, err
+	}
+	return Send(square, `sqrt`, nil)
+}
+
+// normalized answers the array divided by its norm
+func normalized(receiver *SmalltalkArray) (*SmalltalkArray, error) {
+	length, err := norm(receiver)
+	if err != nil {
+		return nil, err
+	}
+	return elementwise(receiver, `/`, length)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Call sends the message name to receiver using the function with this name from m. Functions are
+// called through reflection, so they may have any signature accepted by RegisterMethod.
+func Call(receiver SmalltalkObjectInterface, m map[string]interface{}, name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	f, ok := m[name]
+	if !ok {
+		return nil, &DoesNotUnderstandError{Selector: name, ReceiverType: typeOfObject(receiver)}
+	}
+	if receiver != nil && receiver.TypeOf() == DEFERRED {
+		deferredValue, err := valueOf(receiver)
+		if err != nil {
+			return nil, err
+		}
+		receiver = deferredValue
+	}
+	args, err := deferredValues(params)
+	if err != nil {
+		return nil, err
+	}
+	return callReflected(reflect.ValueOf(f), name, receiver, args)
+}
+
+func callReflected(function reflect.Value, name string, receiver SmalltalkObjectInterface, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if function.Kind() != reflect.Func || function.Type().NumOut() < 1 || function.Type().NumOut() > 2 {
+		return nil, errors.New("method for #" + name + " should be a function answering a smalltalk object")
+	}
+	if function.Type().IsVariadic() || len(params)+1 != function.Type().NumIn() {
+		return nil, &WrongArgumentCountError{Selector: name, Expected: function.Type().NumIn() - 1, Actual: len(params)}
+	}
+	in := make([]reflect.Value, len(params)+1)
+	for k, param := range append([]SmalltalkObjectInterface{receiver}, params...) {
+		if param == nil {
+			return nil, &TypeMismatchError{Expected: typeNameOf(function.Type().In(k)), Actual: UNDEFINED_OBJ, Selector: name, ReceiverType: typeOfObject(receiver)}
+		}
 		in[k] = reflect.ValueOf(param)
+		if !in[k].Type().AssignableTo(function.Type().In(k)) {
+			return nil, &TypeMismatchError{Expected: typeNameOf(function.Type().In(k)), Actual: param.TypeOf(), Selector: name, ReceiverType: typeOfObject(receiver)}
+		}
 	}
 	result := function.Call(in)
-	return result[0].Interface().(SmalltalkObjectInterface), nil
+	if len(result) > 1 {
+		if err, ok := result[1].Interface().(error); ok && err != nil {
+			return nil, err
+		}
+	}
+	object, ok := result[0].Interface().(SmalltalkObjectInterface)
+	if !ok || (result[0].Kind() == reflect.Ptr && result[0].IsNil()) {
+		return NewSmalltalkUndefinedObject(), nil
+	}
+	return object, nil
+}
+
+func typeOfObject(object SmalltalkObjectInterface) string {
+	if object == nil {
+		return UNDEFINED_OBJ
+	}
+	return object.TypeOf()
+}
+
+// typeNameOf answers the smalltalk type name of the primitive parameter type
+func typeNameOf(parameterType reflect.Type) string {
+	if parameterType.Kind() == reflect.Ptr {
+		if object, ok := reflect.New(parameterType.Elem()).Interface().(SmalltalkObjectInterface); ok {
+			return object.TypeOf()
+		}
+	}
+	return "OBJECT"
+}
+
+// cullValue answers the result of the block evaluation with arg if the block has an argument,
+// or the value of the object otherwise
+func cullValue(object SmalltalkObjectInterface, arg SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if block, ok := object.(*SmalltalkBlock); ok {
+		return cull(block, []SmalltalkObjectInterface{arg})
+	}
+	return valueOf(object)
+}
+
+// valueOf answers the object itself or, for blocks, the result of their evaluation
+func valueOf(object SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if block, ok := object.(blockInterface); ok {
+		return block.ValueWithArguments(nil)
+	}
+	return object.Value(), nil
 }
 
 type SmalltalkObjectInterface interface {
@@ -418,7 +1432,7 @@ type SmalltalkObject struct {
 }
 
 func (obj *SmalltalkObject) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
-	return nil, nil
+	return nil, &DoesNotUnderstandError{Selector: name, ReceiverType: "OBJECT"}
 }
 
 type SmalltalkUndefinedObject struct {
@@ -434,20 +1448,30 @@ func (n *SmalltalkUndefinedObject) Value() SmalltalkObjectInterface {
 }
 
 func (n *SmalltalkUndefinedObject) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
-	return nil, errors.New("doesNotUnderstand")
+	return performMethod(n, undefinedMessages, name, params)
 }
 
 func (n *SmalltalkUndefinedObject) TypeOf() string {
 	return UNDEFINED_OBJ
 }
 
+// SmalltalkNumber is a Float, a SmallInteger, a LargeInteger, a Fraction or a ScaledDecimal. Value of an exact number is kept in value as float64 too,
+// so float operations do not depend on the kind of the number.
 type SmalltalkNumber struct {
 	*SmalltalkObject
-	value float64
+	value    float64
+	kind     numberKind
+	integer  int64
+	large    *big.Int
+	rational *big.Rat
+	scale    int
+	// fault is set when a division by zero or a function outside of its domain answered this number
+	fault *numericFault
 }
 
+// NewSmalltalkNumber answers a Float
 func NewSmalltalkNumber(value float64) *SmalltalkNumber {
-	return &SmalltalkNumber{&SmalltalkObject{}, value}
+	return &SmalltalkNumber{SmalltalkObject: &SmalltalkObject{}, value: value}
 }
 
 func (n *SmalltalkNumber) Value() SmalltalkObjectInterface {
@@ -455,7 +1479,7 @@ func (n *SmalltalkNumber) Value() SmalltalkObjectInterface {
 }
 
 func (n *SmalltalkNumber) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
-	return Call(n, numberMessages, name, params)
+	return performMethod(n, numberMessages, name, params)
 }
 
 func (n *SmalltalkNumber) TypeOf() string {
@@ -468,6 +1492,9 @@ func (n *SmalltalkNumber) GetValue() float64 {
 
 func (n *SmalltalkNumber) SetValue(val float64) *SmalltalkNumber {
 	n.value = val
+	n.kind = floatNumber
+	n.large = nil
+	n.rational = nil
 	return n
 }
 
@@ -488,6 +1515,10 @@ func (s *SmalltalkString) TypeOf() string {
 	return STRING_OBJ
 }
 
+func (s *SmalltalkString) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(s, stringMessages, name, params)
+}
+
 func (s *SmalltalkString) GetValue() string {
 	return s.value
 }
@@ -497,6 +1528,47 @@ func (s *SmalltalkString) SetValue(val string) *SmalltalkString {
 	return s
 }
 
+// SmalltalkSymbol is a unique name. There is only one symbol with every name, so symbols are compared by identity.
+type SmalltalkSymbol struct {
+	*SmalltalkObject
+	name string
+}
+
+// symbolTable interns symbols for the lifetime of the process. Symbols are never removed, so scripts which make
+// symbols from unbounded input, like `aString asSymbol` for every line of a file, grow it without bound.
+var symbolTable = struct {
+	sync.Mutex
+	symbols map[string]*SmalltalkSymbol
+}{symbols: make(map[string]*SmalltalkSymbol)}
+
+// NewSmalltalkSymbol answers the symbol with name, creating it only the first time
+func NewSmalltalkSymbol(name string) *SmalltalkSymbol {
+	symbolTable.Lock()
+	defer symbolTable.Unlock()
+	symbol, ok := symbolTable.symbols[name]
+	if !ok {
+		symbol = &SmalltalkSymbol{&SmalltalkObject{}, name}
+		symbolTable.symbols[name] = symbol
+	}
+	return symbol
+}
+
+func (s *SmalltalkSymbol) Value() SmalltalkObjectInterface {
+	return s
+}
+
+func (s *SmalltalkSymbol) TypeOf() string {
+	return SYMBOL_OBJ
+}
+
+func (s *SmalltalkSymbol) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(s, symbolMessages, name, params)
+}
+
+func (s *SmalltalkSymbol) GetValue() string {
+	return s.name
+}
+
 type SmalltalkBoolean struct {
 	*SmalltalkObject
 	value bool
@@ -524,17 +1596,72 @@ func (b *SmalltalkBoolean) SetValue(val bool) *SmalltalkBoolean {
 }
 
 func (b *SmalltalkBoolean) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
-	return Call(b, booleanMessages, name, params)
+	return performMethod(b, booleanMessages, name, params)
+}
+
+type blockInterface interface {
+	ValueWithArguments(arguments []SmalltalkObjectInterface) (SmalltalkObjectInterface, error)
 }
 
 type SmalltalkBlock struct {
 	*SmalltalkObject
 	block *BlockNode
 	scope *Scope
+	home  *Context
+	// handlers are the on:do: messages around the message which evaluates the block, see withHandlers
+	handlers *handlerFrame
 }
 
+// Value answers the result of the block evaluation without arguments, or nil if the evaluation fails.
+// Use ValueE to get the error.
 func (b *SmalltalkBlock) Value() SmalltalkObjectInterface {
-	return b.block.body.Eval(b.scope)
+	result, _ := b.ValueE()
+	return result
+}
+
+// ValueE evaluates the block without arguments and answers the evaluation error too. An error of a primitive
+// which no on:do: handles is answered as it is, like by the evaluator.
+func (b *SmalltalkBlock) ValueE() (SmalltalkObjectInterface, error) {
+	result, err := b.ValueWithArguments(nil)
+	if err != nil {
+		return nil, unhandledError(err)
+	}
+	return result, nil
+}
+
+// ValueWithArguments evaluates the block in a new scope, so arguments and temporaries are fresh in every activation
+func (b *SmalltalkBlock) ValueWithArguments(arguments []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if len(arguments) != b.NumArgs() {
+		return nil, &WrongArgumentCountError{Expected: b.NumArgs(), Actual: len(arguments)}
+	}
+	scope := new(Scope).Initialize()
+	scope.OuterScope = b.scope
+	scope.SetContext(b.home)
+	scope.handlers = b.handlers
+	for i, arg := range arguments {
+		scope.SetVar(b.block.arguments[i].GetName(), arg)
+	}
+	return b.block.body.Eval(scope)
+}
+
+// withHandlers answers the block for an evaluation inside of the on:do: messages of handlers. A block which is stored
+// in a variable finds the handlers around the place where it is evaluated instead of those where it was created.
+func (b *SmalltalkBlock) withHandlers(handlers *handlerFrame) *SmalltalkBlock {
+	evaluated := *b
+	evaluated.handlers = handlers
+	return &evaluated
+}
+
+// activeHandlers answers the innermost on:do: around the evaluation of the block
+func (b *SmalltalkBlock) activeHandlers() *handlerFrame {
+	if b.handlers != nil {
+		return b.handlers
+	}
+	return b.scope.activeHandlers()
+}
+
+func (b *SmalltalkBlock) NumArgs() int {
+	return len(b.block.arguments)
 }
 
 func (b *SmalltalkBlock) TypeOf() string {
@@ -542,7 +1669,7 @@ func (b *SmalltalkBlock) TypeOf() string {
 }
 
 func (b *SmalltalkBlock) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
-	return Call(b, blockMessages, name, params)
+	return performMethod(b, blockMessages, name, params)
 }
 
 type SmalltalkArray struct {
@@ -550,31 +1677,24 @@ type SmalltalkArray struct {
 	array []SmalltalkObjectInterface
 }
 
+func NewSmalltalkArray(elements []SmalltalkObjectInterface) *SmalltalkArray {
+	return &SmalltalkArray{array: elements}
+}
+
 func (a *SmalltalkArray) GetValueAt(index int64) SmalltalkObjectInterface {
 	return a.array[index]
 }
 
 func (a *SmalltalkArray) GetValue() ([]interface{}, error) {
-	var interfaceSlice = make([]interface{}, len(a.array))
-	for i, each := range a.array {
-		switch each.TypeOf() {
-		case NUMBER_OBJ:
-			interfaceSlice[i] = each.(*SmalltalkNumber).GetValue()
-		case STRING_OBJ:
-			interfaceSlice[i] = each.(*SmalltalkString).GetValue()
-		case BOOLEAN_OBJ:
-			interfaceSlice[i] = each.(*SmalltalkBoolean).GetValue()
-		case ARRAY_OBJ:
-			innerArray, err := each.(*SmalltalkArray).GetValue()
-			if err != nil {
-				return nil, err
-			}
-			interfaceSlice[i] = innerArray
-		default:
-			return nil, errors.New(`we do not support this type "` + each.TypeOf() + `" in array`)
-		}
-	}
-	return interfaceSlice, nil
+	return interfaceValues(a, a.array, nil)
+}
+
+func (a *SmalltalkArray) elements() []SmalltalkObjectInterface {
+	return append([]SmalltalkObjectInterface{}, a.array...)
+}
+
+func (a *SmalltalkArray) species(elements []SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return NewSmalltalkArray(elements)
 }
 
 func (a *SmalltalkArray) Value() SmalltalkObjectInterface {
@@ -586,7 +1706,7 @@ func (a *SmalltalkArray) TypeOf() string {
 }
 
 func (a *SmalltalkArray) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
-	return Call(a, arrayMessages, name, params)
+	return performMethod(a, arrayMessages, name, params)
 }
 
 type Deferred struct {
@@ -598,5 +1718,5 @@ func (d *Deferred) TypeOf() string {
 }
 
 func NewDeferred(blockNode *BlockNode, scope *Scope) *Deferred {
-	return &Deferred{&SmalltalkBlock{&SmalltalkObject{}, blockNode, scope}}
+	return &Deferred{&SmalltalkBlock{&SmalltalkObject{}, blockNode, scope, scope.GetContext(), nil}}
 }
@@ -0,0 +1,227 @@
+package treeNodes
+
+import (
+	"errors"
+)
+
+const ORDERED_COLLECTION_OBJ = "ORDERED_COLLECTION"
+
+var orderedCollectionMessages = withCollectionMessages(map[string]Method{
+	`add:`:             binaryMethodE(orderedCollectionAdd),
+	`addFirst:`:        binaryMethodE(orderedCollectionAddFirst),
+	`addLast:`:         binaryMethodE(orderedCollectionAddLast),
+	`addAll:`:          binaryMethodE(orderedCollectionAddAll),
+	`remove:`:          binaryMethodE(orderedCollectionRemove),
+	`remove:ifAbsent:`: ternaryMethodE(orderedCollectionRemoveIfAbsent),
+	`removeFirst`:      unaryMethodE(orderedCollectionRemoveFirst),
+	`removeLast`:       unaryMethodE(orderedCollectionRemoveLast),
+	`at:`:              binaryMethodE(orderedCollectionAt),
+	`at:put:`:          ternaryMethodE(orderedCollectionAtPut),
+	`first`:            unaryMethodE(orderedCollectionFirst),
+	`last`:             unaryMethodE(orderedCollectionLast),
+}, sequenceableMessages)
+
+// orderedCollectionClassMessages are understood by the OrderedCollection global
+var orderedCollectionClassMessages = map[string]Method{
+	`new`:                  unaryMethod(newOrderedCollection),
+	`new:`:                 binaryMethod(newOrderedCollectionWithCapacity),
+	`with:`:                variadicMethodE(orderedCollectionWith),
+	`with:with:`:           variadicMethodE(orderedCollectionWith),
+	`with:with:with:`:      variadicMethodE(orderedCollectionWith),
+	`with:with:with:with:`: variadicMethodE(orderedCollectionWith),
+	`withAll:`:             binaryMethod(orderedCollectionWithAll),
+}
+
+func init() {
+	globals[`OrderedCollection`] = NewSmalltalkClass(`OrderedCollection`, orderedCollectionClassMessages)
+}
+
+// OrderedCollection methods
+func orderedCollectionAdd(receiver *SmalltalkOrderedCollection, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	err := receiver.add(element)
+	if err != nil {
+		return nil, err
+	}
+	return element, nil
+}
+
+func orderedCollectionAddFirst(receiver *SmalltalkOrderedCollection, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if receiver.sorted {
+		return nil, errors.New("addFirst: should not be sent to a SortedCollection, use add:")
+	}
+	receiver.array = append([]SmalltalkObjectInterface{element}, receiver.array...)
+	return element, nil
+}
+
+func orderedCollectionAddLast(receiver *SmalltalkOrderedCollection, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if receiver.sorted {
+		return nil, errors.New("addLast: should not be sent to a SortedCollection, use add:")
+	}
+	receiver.array = append(receiver.array, element)
+	return element, nil
+}
+
+func orderedCollectionAddAll(receiver *SmalltalkOrderedCollection, elements collection) (SmalltalkObjectInterface, error) {
+	for _, each := range elements.elements() {
+		err := receiver.add(each)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return elements, nil
+}
+
+func orderedCollectionRemove(receiver *SmalltalkOrderedCollection, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	i := indexOfElement(receiver.array, element)
+	if i < 0 {
+		return nil, errors.New(displayString(element) + " not found")
+	}
+	receiver.array = append(receiver.array[:i], receiver.array[i+1:]...)
+	return element, nil
+}
+
+func orderedCollectionRemoveIfAbsent(receiver *SmalltalkOrderedCollection, element SmalltalkObjectInterface, absentBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	i := indexOfElement(receiver.array, element)
+	if i < 0 {
+		return valueOf(absentBlock)
+	}
+	receiver.array = append(receiver.array[:i], receiver.array[i+1:]...)
+	return element, nil
+}
+
+func orderedCollectionRemoveFirst(receiver *SmalltalkOrderedCollection) (SmalltalkObjectInterface, error) {
+	if len(receiver.array) == 0 {
+		return nil, &EmptyCollectionError{}
+	}
+	first := receiver.array[0]
+	receiver.array = receiver.array[1:]
+	return first, nil
+}
+
+func orderedCollectionRemoveLast(receiver *SmalltalkOrderedCollection) (SmalltalkObjectInterface, error) {
+	if len(receiver.array) == 0 {
+		return nil, &EmptyCollectionError{}
+	}
+	last := receiver.array[len(receiver.array)-1]
+	receiver.array = receiver.array[:len(receiver.array)-1]
+	return last, nil
+}
+
+func orderedCollectionAt(receiver *SmalltalkOrderedCollection, index *SmalltalkNumber) (SmalltalkObjectInterface, error) {
+	i, err := offsetOf(index, len(receiver.array))
+	if err != nil {
+		return nil, err
+	}
+	return receiver.array[i], nil
+}
+
+func orderedCollectionAtPut(receiver *SmalltalkOrderedCollection, index *SmalltalkNumber, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if receiver.sorted {
+		return nil, errors.New("at:put: should not be sent to a SortedCollection, use add:")
+	}
+	i, err := offsetOf(index, len(receiver.array))
+	if err != nil {
+		return nil, err
+	}
+	receiver.array[i] = element
+	return element, nil
+}
+
+func orderedCollectionFirst(receiver *SmalltalkOrderedCollection) (SmalltalkObjectInterface, error) {
+	return orderedCollectionAt(receiver, NewSmalltalkInteger(1))
+}
+
+func orderedCollectionLast(receiver *SmalltalkOrderedCollection) (SmalltalkObjectInterface, error) {
+	return orderedCollectionAt(receiver, NewSmalltalkInteger(int64(len(receiver.array))))
+}
+
+// OrderedCollection class methods
+func newOrderedCollection(receiver *SmalltalkClass) *SmalltalkOrderedCollection {
+	return NewSmalltalkOrderedCollection(nil)
+}
+
+func newOrderedCollectionWithCapacity(receiver *SmalltalkClass, capacity *SmalltalkNumber) *SmalltalkOrderedCollection {
+	return NewSmalltalkOrderedCollection(nil)
+}
+
+func orderedCollectionWith(receiver *SmalltalkClass, elements []SmalltalkObjectInterface) (*SmalltalkOrderedCollection, error) {
+	return NewSmalltalkOrderedCollection(elements), nil
+}
+
+func orderedCollectionWithAll(receiver *SmalltalkClass, elements collection) *SmalltalkOrderedCollection {
+	return NewSmalltalkOrderedCollection(elements.elements())
+}
+
+// SmalltalkOrderedCollection is a growable sequence of objects. With a sort block it is a SortedCollection,
+// which keeps its elements sorted when they are added.
+type SmalltalkOrderedCollection struct {
+	*SmalltalkObject
+	array     []SmalltalkObjectInterface
+	sortBlock *SmalltalkBlock
+	sorted    bool
+}
+
+func NewSmalltalkOrderedCollection(elements []SmalltalkObjectInterface) *SmalltalkOrderedCollection {
+	return &SmalltalkOrderedCollection{SmalltalkObject: &SmalltalkObject{}, array: append([]SmalltalkObjectInterface{}, elements...)}
+}
+
+// NewSmalltalkSortedCollection answers a SortedCollection of elements. Without sortBlock elements are sorted with <=.
+func NewSmalltalkSortedCollection(elements []SmalltalkObjectInterface, sortBlock *SmalltalkBlock) (*SmalltalkOrderedCollection, error) {
+	result := NewSmalltalkOrderedCollection(elements)
+	result.sortBlock = sortBlock
+	result.sorted = true
+	err := sortElements(result.array, sortBlock)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// add adds element at the end or, to a SortedCollection, after the elements which are before it or equal to it.
+// A SortedCollection does not change when the sort block fails.
+func (c *SmalltalkOrderedCollection) add(element SmalltalkObjectInterface) error {
+	if !c.sorted {
+		c.array = append(c.array, element)
+		return nil
+	}
+	i, err := insertionIndex(c.array, element, c.sortBlock)
+	if err != nil {
+		return err
+	}
+	c.array = append(c.array, nil)
+	copy(c.array[i+1:], c.array[i:])
+	c.array[i] = element
+	return nil
+}
+
+func (c *SmalltalkOrderedCollection) elements() []SmalltalkObjectInterface {
+	return append([]SmalltalkObjectInterface{}, c.array...)
+}
+
+func (c *SmalltalkOrderedCollection) species(elements []SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return NewSmalltalkOrderedCollection(elements)
+}
+
+func (c *SmalltalkOrderedCollection) displayString(visited visitedObjects) string {
+	if c.sorted {
+		return collectionDisplayString("SortedCollection", c.array, visited)
+	}
+	return collectionDisplayString("OrderedCollection", c.array, visited)
+}
+
+func (c *SmalltalkOrderedCollection) Value() SmalltalkObjectInterface {
+	return c
+}
+
+func (c *SmalltalkOrderedCollection) TypeOf() string {
+	return ORDERED_COLLECTION_OBJ
+}
+
+func (c *SmalltalkOrderedCollection) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(c, orderedCollectionMessages, name, params)
+}
+
+// GetValue answers the elements converted to Go values like EvaluateToInterface does
+func (c *SmalltalkOrderedCollection) GetValue() ([]interface{}, error) {
+	return interfaceValues(c, c.array, nil)
+}
@@ -0,0 +1,145 @@
+package treeNodes
+
+import (
+	"errors"
+)
+
+const SET_OBJ = "SET"
+
+var setMessages = withCollectionMessages(map[string]Method{
+	`add:`:             binaryMethod(setAdd),
+	`addAll:`:          binaryMethod(setAddAll),
+	`remove:`:          binaryMethodE(setRemove),
+	`remove:ifAbsent:`: ternaryMethodE(setRemoveIfAbsent),
+	`includes:`:        binaryMethod(setIncludes),
+})
+
+// setClassMessages are understood by the Set global
+var setClassMessages = map[string]Method{
+	`new`:                  unaryMethod(newSet),
+	`new:`:                 binaryMethod(newSetWithCapacity),
+	`with:`:                variadicMethodE(setWith),
+	`with:with:`:           variadicMethodE(setWith),
+	`with:with:with:`:      variadicMethodE(setWith),
+	`with:with:with:with:`: variadicMethodE(setWith),
+	`withAll:`:             binaryMethod(setWithAll),
+}
+
+func init() {
+	globals[`Set`] = NewSmalltalkClass(`Set`, setClassMessages)
+}
+
+// Set methods
+func setAdd(receiver *SmalltalkSet, element SmalltalkObjectInterface) SmalltalkObjectInterface {
+	receiver.add(element)
+	return element
+}
+
+func setAddAll(receiver *SmalltalkSet, elements collection) SmalltalkObjectInterface {
+	for _, each := range elements.elements() {
+		receiver.add(each)
+	}
+	return elements
+}
+
+func setRemove(receiver *SmalltalkSet, element SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if !receiver.remove(element) {
+		return nil, errors.New(displayString(element) + " not found")
+	}
+	return element, nil
+}
+
+func setRemoveIfAbsent(receiver *SmalltalkSet, element SmalltalkObjectInterface, absentBlock SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	if !receiver.remove(element) {
+		return valueOf(absentBlock)
+	}
+	return element, nil
+}
+
+func setIncludes(receiver *SmalltalkSet, element SmalltalkObjectInterface) *SmalltalkBoolean {
+	_, ok := receiver.index[equalityKey(element)]
+	return NewSmalltalkBoolean(ok)
+}
+
+// Set class methods
+func newSet(receiver *SmalltalkClass) *SmalltalkSet {
+	return NewSmalltalkSet(nil)
+}
+
+func newSetWithCapacity(receiver *SmalltalkClass, capacity *SmalltalkNumber) *SmalltalkSet {
+	return NewSmalltalkSet(nil)
+}
+
+func setWith(receiver *SmalltalkClass, elements []SmalltalkObjectInterface) (*SmalltalkSet, error) {
+	return NewSmalltalkSet(elements), nil
+}
+
+func setWithAll(receiver *SmalltalkClass, elements collection) *SmalltalkSet {
+	return NewSmalltalkSet(elements.elements())
+}
+
+// SmalltalkSet is a collection without equal elements. Elements are enumerated in the order they were added.
+type SmalltalkSet struct {
+	*SmalltalkObject
+	array []SmalltalkObjectInterface
+	index map[interface{}]int
+}
+
+func NewSmalltalkSet(elements []SmalltalkObjectInterface) *SmalltalkSet {
+	set := &SmalltalkSet{&SmalltalkObject{}, nil, make(map[interface{}]int)}
+	for _, each := range elements {
+		set.add(each)
+	}
+	return set
+}
+
+func (s *SmalltalkSet) add(element SmalltalkObjectInterface) {
+	key := equalityKey(element)
+	if _, ok := s.index[key]; ok {
+		return
+	}
+	s.index[key] = len(s.array)
+	s.array = append(s.array, element)
+}
+
+func (s *SmalltalkSet) remove(element SmalltalkObjectInterface) bool {
+	i, ok := s.index[equalityKey(element)]
+	if !ok {
+		return false
+	}
+	s.array = append(s.array[:i], s.array[i+1:]...)
+	s.index = make(map[interface{}]int)
+	for offset, each := range s.array {
+		s.index[equalityKey(each)] = offset
+	}
+	return true
+}
+
+func (s *SmalltalkSet) elements() []SmalltalkObjectInterface {
+	return append([]SmalltalkObjectInterface{}, s.array...)
+}
+
+func (s *SmalltalkSet) species(elements []SmalltalkObjectInterface) SmalltalkObjectInterface {
+	return NewSmalltalkSet(elements)
+}
+
+func (s *SmalltalkSet) displayString(visited visitedObjects) string {
+	return collectionDisplayString("Set", s.array, visited)
+}
+
+func (s *SmalltalkSet) Value() SmalltalkObjectInterface {
+	return s
+}
+
+func (s *SmalltalkSet) TypeOf() string {
+	return SET_OBJ
+}
+
+func (s *SmalltalkSet) Perform(name string, params []SmalltalkObjectInterface) (SmalltalkObjectInterface, error) {
+	return performMethod(s, setMessages, name, params)
+}
+
+// GetValue answers the elements converted to Go values like EvaluateToInterface does
+func (s *SmalltalkSet) GetValue() ([]interface{}, error) {
+	return interfaceValues(s, s.array, nil)
+}